@@ -1,6 +1,7 @@
 package app
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/go-chi/chi"
@@ -11,15 +12,25 @@ import (
 	"notes-service-go/internal/config"
 	"notes-service-go/internal/database"
 	"notes-service-go/internal/delivery/handlers"
+	"notes-service-go/internal/delivery/middleware"
 	"notes-service-go/internal/service"
 	"notes-service-go/pkg/auth"
+	"notes-service-go/pkg/crypt"
 	"notes-service-go/pkg/hash"
+	"notes-service-go/pkg/lockout"
+	"notes-service-go/pkg/mail"
+	"notes-service-go/pkg/policy"
+	"notes-service-go/pkg/ratelimit"
 	"notes-service-go/pkg/spell"
+	"reflect"
+	"strings"
 )
 
 const (
 	errLoadingConfig  = "error loading config"
 	errConnectingToDb = "error connecting to db"
+	errLoadingKeys    = "error loading access token keys"
+	errCreatingCipher = "error creating totp secret cipher"
 
 	successfulConfigLoad   = "config has been loaded successfully"
 	successfulDBConnection = "successful connection to db"
@@ -42,20 +53,160 @@ func Run() {
 	log.Println(successfulDBConnection)
 	queries := database.New(conn)
 
-	hasher := hash.NewBcryptHasher()
+	hasher := hash.NewArgon2idHasher(hash.Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+		SaltLength:  hash.Argon2SaltLength,
+		KeyLength:   hash.Argon2KeyLength,
+	})
+	tokenHasher := hash.NewHMACHasher(cfg.RefreshHashKey)
 	speller := spell.NewYandexSpeller(cfg.SpellerURL)
-	tokenManager := auth.NewManager(cfg.AccessTTL, cfg.RefreshTTL, cfg.AccessSigningKey, cfg.RefreshSigningKey, hasher)
+	mailer := newMailer(cfg)
+	secretCipher, err := crypt.NewAESGCMCipher(cfg.TOTPSecretKey)
+	if err != nil {
+		log.Fatalf(errCreatingCipher+": %s\n", err)
+	}
+	var revocations auth.RevocationStore = auth.NewMemoryRevocationStore()
+	if cfg.RevocationStore == config.RevocationStorePostgres {
+		revocations = service.NewPostgresRevocationStore(queries)
+	}
+	go auth.CleanupRevocations(context.Background(), revocations)
+
+	var lockouts lockout.Store = lockout.NewMemoryStore()
+	if cfg.LoginLockoutStore == config.LoginLockoutStorePostgres {
+		lockouts = service.NewPostgresLockoutStore(queries)
+	}
+	go lockout.Cleanup(context.Background(), lockouts)
+	loginLimiter := lockout.NewLimiter(lockouts, lockout.Policy{
+		MaxFailures: cfg.LoginMaxFailures,
+		BaseDelay:   cfg.LoginLockoutBase,
+		MaxDelay:    cfg.LoginLockoutMax,
+		Window:      cfg.LoginFailureTTL,
+	})
+	ipLimiter := lockout.NewLimiter(lockouts, lockout.Policy{
+		MaxFailures: cfg.LoginIPMaxFailures,
+		BaseDelay:   cfg.LoginLockoutBase,
+		MaxDelay:    cfg.LoginLockoutMax,
+		Window:      cfg.LoginFailureTTL,
+	})
+
+	accessKeys, err := loadAccessKeys(cfg.AccessSigningKeys, cfg.AccessSigningKey)
+	if err != nil {
+		log.Fatalf(errLoadingKeys+": %s\n", err)
+	}
+
+	tokenManager := auth.NewManager(cfg.AccessTTL, cfg.RefreshTTL, accessKeys, cfg.RefreshSigningKey, auth.ClaimsConfig{
+		Issuer:   cfg.TokenIssuer,
+		Audience: cfg.TokenAudience,
+		Leeway:   cfg.TokenLeeway,
+	}, tokenHasher, revocations)
+	var rateLimits ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == config.RateLimitStorePostgres {
+		rateLimits = service.NewPostgresRateLimitStore(queries)
+	}
+	go ratelimit.Cleanup(context.Background(), rateLimits)
+
 	services := service.NewServices(service.Deps{
+		DB:           conn,
 		Repo:         queries,
 		Hasher:       hasher,
+		TokenHasher:  tokenHasher,
+		SecretCipher: secretCipher,
 		Speller:      speller,
 		TokenManager: tokenManager,
+		Mailer:       mailer,
+		LoginLimiter: loginLimiter,
+		IPLimiter:    ipLimiter,
+		TOTPIssuer:   cfg.TOTPIssuer,
+
+		LoginPolicy: policy.LoginPolicy{
+			MinLength:      cfg.LoginMinLength,
+			MaxLength:      cfg.LoginMaxLength,
+			AllowedSymbols: cfg.LoginAllowedSymbols,
+		},
+		PasswordPolicy: policy.PasswordPolicy{
+			MinLength:    cfg.PasswordMinLength,
+			MaxLength:    cfg.PasswordMaxLength,
+			MinClasses:   cfg.PasswordMinClasses,
+			RejectLogin:  cfg.PasswordRejectLogin,
+			RejectCommon: cfg.PasswordRejectCommon,
+		},
+
+		RefreshTokenTTL:  cfg.RefreshTTL,
+		PasswordResetTTL: cfg.PasswordResetTTL,
+		EmailVerifyTTL:   cfg.EmailVerifyTTL,
 	})
 
+	go service.NewTrashPurger(queries, cfg.TrashRetention).Run(context.Background())
+
 	r := chi.NewRouter()
-	h := handlers.NewHandler(services, validator.New(), cfg.RefreshTTL)
+	r.Use(middleware.RealIP(cfg.TrustedProxies))
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	h := handlers.NewHandler(services, tokenManager, validate, cfg.RefreshTTL, handlers.Timeouts{
+		Request:   cfg.RequestTimeout,
+		Search:    cfg.SearchTimeout,
+		NoteWrite: cfg.NoteWriteTimeout,
+	}, handlers.RateLimits{
+		Limiter:   ratelimit.NewLimiter(rateLimits),
+		Default:   cfg.DefaultRateLimit,
+		Auth:      cfg.AuthRateLimit,
+		Search:    cfg.SearchRateLimit,
+		NoteWrite: cfg.NoteWriteRateLimit,
+	})
 	h.RegisterRoutes(r)
 
 	log.Printf(serverStart+" %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
 }
+
+func newMailer(cfg *config.Config) mail.Mailer {
+	switch cfg.Mailer {
+	case config.MailerSMTP:
+		return mail.NewSMTPMailer(cfg.SMTPAddr, cfg.MailFrom, cfg.SMTPUsername, cfg.SMTPPassword)
+	case config.MailerFile:
+		return mail.NewFileMailer(cfg.MailFrom, cfg.MailFile)
+	default:
+		return mail.NewLogMailer()
+	}
+}
+
+// loadAccessKeys loads the access token keys, the first of them signs new
+// tokens. Without key files the tokens are signed with the legacy secret, with
+// them the secret only verifies the tokens it signed before the migration.
+func loadAccessKeys(files []config.KeyFile, legacySecret string) (*auth.KeySet, error) {
+	if len(files) == 0 {
+		return auth.NewLegacyKeySet(legacySecret)
+	}
+
+	keys := make([]auth.SigningKey, 0, len(files))
+	for _, file := range files {
+		key, err := auth.LoadSigningKey(file.ID, file.Path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	keySet, err := auth.NewKeySet(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	if legacySecret != "" {
+		keySet = keySet.WithLegacySecret(legacySecret)
+	}
+
+	return keySet, nil
+}
+
+// jsonFieldName makes validation errors name fields the way clients send them.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
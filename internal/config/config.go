@@ -3,23 +3,146 @@ package config
 import (
 	"errors"
 	"github.com/joho/godotenv"
+	"net/netip"
 	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/ratelimit"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Defaults of the settings that may be left unset, so that environments made
+// before the settings were added keep working.
+const (
+	defaultTrashRetention       = "720h"
+	defaultRequestTimeout       = "5s"
+	defaultSearchTimeout        = "10s"
+	defaultNoteWriteTimeout     = "15s"
+	defaultRevocationStore      = RevocationStoreMemory
+	defaultTokenIssuer          = "notes-service-go"
+	defaultTokenAudience        = "notes-service-go"
+	defaultTokenLeeway          = "30s"
+	defaultMailer               = MailerLog
+	defaultMailFrom             = "noreply@notes-service.local"
+	defaultPasswordResetTTL     = "1h"
+	defaultEmailVerifyTTL       = "24h"
+	defaultTOTPIssuer           = "notes-service-go"
+	defaultLoginLockoutStore    = LoginLockoutStoreMemory
+	defaultLoginMaxFailures     = "5"
+	defaultLoginIPMaxFailures   = "50"
+	defaultLoginLockoutBase     = "1s"
+	defaultLoginLockoutMax      = "15m"
+	defaultLoginFailureTTL      = "24h"
+	defaultRateLimitStore       = RateLimitStoreMemory
+	defaultRateLimitDefault     = "300/1m"
+	defaultRateLimitAuth        = "30/1m"
+	defaultRateLimitSearch      = "60/1m"
+	defaultRateLimitNoteWrite   = "30/1m"
+	defaultArgon2Memory         = "65536"
+	defaultArgon2Iterations     = "3"
+	defaultArgon2Parallelism    = "2"
+	defaultLoginMinLength       = "3"
+	defaultLoginMaxLength       = "32"
+	defaultLoginAllowedSymbols  = "._-"
+	defaultPasswordMinLength    = "10"
+	defaultPasswordMaxLength    = "128"
+	defaultPasswordMinClasses   = "3"
+	defaultPasswordRejectLogin  = "true"
+	defaultPasswordRejectCommon = "true"
+)
+
+// Kinds of the access token revocation store. The memory store only works for
+// a single instance of the service.
+const (
+	RevocationStoreMemory   = "memory"
+	RevocationStorePostgres = "postgres"
+)
+
+// Kinds of the login lockout store. The memory store only works for a single
+// instance of the service.
+const (
+	LoginLockoutStoreMemory   = "memory"
+	LoginLockoutStorePostgres = "postgres"
+)
+
+// Kinds of the rate limit store. The memory store only works for a single
+// instance of the service.
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+// Kinds of the mailer. The file and log mailers don't send anything, they're
+// meant for development and tests.
+const (
+	MailerSMTP = "smtp"
+	MailerFile = "file"
+	MailerLog  = "log"
+)
+
+// KeyFile is a PEM file with an access token key and the kid the key is
+// published under.
+type KeyFile struct {
+	ID   string
+	Path string
+}
+
 type Config struct {
-	Port              string
-	DbUser            string
-	DbPassword        string
-	DbHost            string
-	DbPort            string
-	DbName            string
-	AccessTTL         time.Duration
-	RefreshTTL        time.Duration
-	AccessSigningKey  string
-	RefreshSigningKey string
-	SpellerURL        string
+	Port                 string
+	DbUser               string
+	DbPassword           string
+	DbHost               string
+	DbPort               string
+	DbName               string
+	AccessTTL            time.Duration
+	RefreshTTL           time.Duration
+	AccessSigningKeys    []KeyFile
+	AccessSigningKey     string
+	RefreshSigningKey    string
+	RefreshHashKey       string
+	Argon2Memory         uint32
+	Argon2Iterations     uint32
+	Argon2Parallelism    uint8
+	LoginMinLength       int
+	LoginMaxLength       int
+	LoginAllowedSymbols  string
+	PasswordMinLength    int
+	PasswordMaxLength    int
+	PasswordMinClasses   int
+	PasswordRejectLogin  bool
+	PasswordRejectCommon bool
+	TOTPIssuer           string
+	TOTPSecretKey        string
+	TokenIssuer          string
+	TokenAudience        string
+	TokenLeeway          time.Duration
+	RevocationStore      string
+	LoginLockoutStore    string
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginLockoutBase     time.Duration
+	LoginLockoutMax      time.Duration
+	LoginFailureTTL      time.Duration
+	TrustedProxies       []netip.Prefix
+	RateLimitStore       string
+	DefaultRateLimit     ratelimit.Limit
+	AuthRateLimit        ratelimit.Limit
+	SearchRateLimit      ratelimit.Limit
+	NoteWriteRateLimit   ratelimit.Limit
+	SpellerURL           string
+	Mailer               string
+	MailFrom             string
+	SMTPAddr             string
+	SMTPUsername         string
+	SMTPPassword         string
+	MailFile             string
+	PasswordResetTTL     time.Duration
+	EmailVerifyTTL       time.Duration
+	TrashRetention       time.Duration
+	RequestTimeout       time.Duration
+	SearchTimeout        time.Duration
+	NoteWriteTimeout     time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -89,10 +212,23 @@ func LoadConfig() (*Config, error) {
 		return nil, errors.New(domain.ErrParsingRefreshTTL)
 	}
 
+	accessSigningKeysStr := os.Getenv("ACCESS_SIGNING_KEYS")
 	accessSigningKey := os.Getenv("ACCESS_SIGNING_KEY")
 
-	if accessSigningKey == "" {
-		return nil, errors.New("ACCESS_SIGNING_KEY " + domain.ErrUndefinedEnvParam)
+	if accessSigningKeysStr == "" && accessSigningKey == "" {
+		return nil, errors.New("ACCESS_SIGNING_KEYS " + domain.ErrUndefinedEnvParam)
+	}
+
+	var accessSigningKeys []KeyFile
+
+	if accessSigningKeysStr != "" {
+		for _, keyStr := range strings.Split(accessSigningKeysStr, ",") {
+			id, path, ok := strings.Cut(strings.TrimSpace(keyStr), ":")
+			if !ok || id == "" || path == "" {
+				return nil, errors.New(domain.ErrParsingAccessSigningKeys)
+			}
+			accessSigningKeys = append(accessSigningKeys, KeyFile{ID: id, Path: path})
+		}
 	}
 
 	refreshSigningKey := os.Getenv("REFRESH_SIGNING_KEY")
@@ -101,23 +237,508 @@ func LoadConfig() (*Config, error) {
 		return nil, errors.New("REFRESH_SIGNING_KEY " + domain.ErrUndefinedEnvParam)
 	}
 
+	refreshHashKey := os.Getenv("REFRESH_HASH_KEY")
+
+	if refreshHashKey == "" {
+		return nil, errors.New("REFRESH_HASH_KEY " + domain.ErrUndefinedEnvParam)
+	}
+
+	argon2MemoryStr := os.Getenv("ARGON2_MEMORY")
+
+	if argon2MemoryStr == "" {
+		argon2MemoryStr = defaultArgon2Memory
+	}
+
+	argon2Memory, err := strconv.ParseUint(argon2MemoryStr, 10, 32)
+
+	if err != nil || argon2Memory == 0 {
+		return nil, errors.New(domain.ErrParsingArgon2Memory)
+	}
+
+	argon2IterationsStr := os.Getenv("ARGON2_ITERATIONS")
+
+	if argon2IterationsStr == "" {
+		argon2IterationsStr = defaultArgon2Iterations
+	}
+
+	argon2Iterations, err := strconv.ParseUint(argon2IterationsStr, 10, 32)
+
+	if err != nil || argon2Iterations == 0 {
+		return nil, errors.New(domain.ErrParsingArgon2Iterations)
+	}
+
+	argon2ParallelismStr := os.Getenv("ARGON2_PARALLELISM")
+
+	if argon2ParallelismStr == "" {
+		argon2ParallelismStr = defaultArgon2Parallelism
+	}
+
+	argon2Parallelism, err := strconv.ParseUint(argon2ParallelismStr, 10, 8)
+
+	if err != nil || argon2Parallelism == 0 {
+		return nil, errors.New(domain.ErrParsingArgon2Parallelism)
+	}
+
+	loginMinLengthStr := os.Getenv("LOGIN_MIN_LENGTH")
+
+	if loginMinLengthStr == "" {
+		loginMinLengthStr = defaultLoginMinLength
+	}
+
+	loginMinLength, err := strconv.Atoi(loginMinLengthStr)
+
+	if err != nil || loginMinLength < 1 {
+		return nil, errors.New(domain.ErrParsingLoginMinLength)
+	}
+
+	loginMaxLengthStr := os.Getenv("LOGIN_MAX_LENGTH")
+
+	if loginMaxLengthStr == "" {
+		loginMaxLengthStr = defaultLoginMaxLength
+	}
+
+	loginMaxLength, err := strconv.Atoi(loginMaxLengthStr)
+
+	if err != nil || loginMaxLength < loginMinLength {
+		return nil, errors.New(domain.ErrParsingLoginMaxLength)
+	}
+
+	// Only letters and digits are allowed in logins if the symbols are set
+	// empty.
+	loginAllowedSymbols, ok := os.LookupEnv("LOGIN_ALLOWED_SYMBOLS")
+
+	if !ok {
+		loginAllowedSymbols = defaultLoginAllowedSymbols
+	}
+
+	passwordMinLengthStr := os.Getenv("PASSWORD_MIN_LENGTH")
+
+	if passwordMinLengthStr == "" {
+		passwordMinLengthStr = defaultPasswordMinLength
+	}
+
+	passwordMinLength, err := strconv.Atoi(passwordMinLengthStr)
+
+	if err != nil || passwordMinLength < 1 {
+		return nil, errors.New(domain.ErrParsingPasswordMinLength)
+	}
+
+	passwordMaxLengthStr := os.Getenv("PASSWORD_MAX_LENGTH")
+
+	if passwordMaxLengthStr == "" {
+		passwordMaxLengthStr = defaultPasswordMaxLength
+	}
+
+	passwordMaxLength, err := strconv.Atoi(passwordMaxLengthStr)
+
+	if err != nil || passwordMaxLength < passwordMinLength {
+		return nil, errors.New(domain.ErrParsingPasswordMaxLength)
+	}
+
+	passwordMinClassesStr := os.Getenv("PASSWORD_MIN_CLASSES")
+
+	if passwordMinClassesStr == "" {
+		passwordMinClassesStr = defaultPasswordMinClasses
+	}
+
+	passwordMinClasses, err := strconv.Atoi(passwordMinClassesStr)
+
+	if err != nil || passwordMinClasses < 0 || passwordMinClasses > 4 {
+		return nil, errors.New(domain.ErrParsingPasswordMinClasses)
+	}
+
+	passwordRejectLoginStr := os.Getenv("PASSWORD_REJECT_LOGIN")
+
+	if passwordRejectLoginStr == "" {
+		passwordRejectLoginStr = defaultPasswordRejectLogin
+	}
+
+	passwordRejectLogin, err := strconv.ParseBool(passwordRejectLoginStr)
+
+	if err != nil {
+		return nil, errors.New(domain.ErrParsingPasswordRejectLogin)
+	}
+
+	passwordRejectCommonStr := os.Getenv("PASSWORD_REJECT_COMMON")
+
+	if passwordRejectCommonStr == "" {
+		passwordRejectCommonStr = defaultPasswordRejectCommon
+	}
+
+	passwordRejectCommon, err := strconv.ParseBool(passwordRejectCommonStr)
+
+	if err != nil {
+		return nil, errors.New(domain.ErrParsingPasswordRejectCommon)
+	}
+
+	totpIssuer := os.Getenv("TOTP_ISSUER")
+
+	if totpIssuer == "" {
+		totpIssuer = defaultTOTPIssuer
+	}
+
+	totpSecretKey := os.Getenv("TOTP_SECRET_KEY")
+
+	if totpSecretKey == "" {
+		return nil, errors.New("TOTP_SECRET_KEY " + domain.ErrUndefinedEnvParam)
+	}
+
+	tokenIssuer := os.Getenv("TOKEN_ISSUER")
+
+	if tokenIssuer == "" {
+		tokenIssuer = defaultTokenIssuer
+	}
+
+	tokenAudience := os.Getenv("TOKEN_AUDIENCE")
+
+	if tokenAudience == "" {
+		tokenAudience = defaultTokenAudience
+	}
+
+	tokenLeewayStr := os.Getenv("TOKEN_LEEWAY")
+
+	if tokenLeewayStr == "" {
+		tokenLeewayStr = defaultTokenLeeway
+	}
+
+	tokenLeeway, err := time.ParseDuration(tokenLeewayStr)
+
+	if err != nil || tokenLeeway < 0 {
+		return nil, errors.New(domain.ErrParsingTokenLeeway)
+	}
+
+	revocationStore := os.Getenv("REVOCATION_STORE")
+
+	if revocationStore == "" {
+		revocationStore = defaultRevocationStore
+	}
+
+	if revocationStore != RevocationStoreMemory && revocationStore != RevocationStorePostgres {
+		return nil, errors.New(domain.ErrInvalidRevocationStore)
+	}
+
+	loginLockoutStore := os.Getenv("LOGIN_LOCKOUT_STORE")
+
+	if loginLockoutStore == "" {
+		loginLockoutStore = defaultLoginLockoutStore
+	}
+
+	if loginLockoutStore != LoginLockoutStoreMemory && loginLockoutStore != LoginLockoutStorePostgres {
+		return nil, errors.New(domain.ErrInvalidLoginLockoutStore)
+	}
+
+	loginMaxFailuresStr := os.Getenv("LOGIN_MAX_FAILURES")
+
+	if loginMaxFailuresStr == "" {
+		loginMaxFailuresStr = defaultLoginMaxFailures
+	}
+
+	loginMaxFailures, err := strconv.Atoi(loginMaxFailuresStr)
+
+	if err != nil || loginMaxFailures < 0 {
+		return nil, errors.New(domain.ErrParsingLoginMaxFailures)
+	}
+
+	loginIPMaxFailuresStr := os.Getenv("LOGIN_IP_MAX_FAILURES")
+
+	if loginIPMaxFailuresStr == "" {
+		loginIPMaxFailuresStr = defaultLoginIPMaxFailures
+	}
+
+	loginIPMaxFailures, err := strconv.Atoi(loginIPMaxFailuresStr)
+
+	if err != nil || loginIPMaxFailures < 0 {
+		return nil, errors.New(domain.ErrParsingLoginIPMaxFailures)
+	}
+
+	loginLockoutBaseStr := os.Getenv("LOGIN_LOCKOUT_BASE")
+
+	if loginLockoutBaseStr == "" {
+		loginLockoutBaseStr = defaultLoginLockoutBase
+	}
+
+	loginLockoutBase, err := time.ParseDuration(loginLockoutBaseStr)
+
+	if err != nil || loginLockoutBase <= 0 {
+		return nil, errors.New(domain.ErrParsingLoginLockoutBase)
+	}
+
+	loginLockoutMaxStr := os.Getenv("LOGIN_LOCKOUT_MAX")
+
+	if loginLockoutMaxStr == "" {
+		loginLockoutMaxStr = defaultLoginLockoutMax
+	}
+
+	loginLockoutMax, err := time.ParseDuration(loginLockoutMaxStr)
+
+	if err != nil || loginLockoutMax < loginLockoutBase {
+		return nil, errors.New(domain.ErrParsingLoginLockoutMax)
+	}
+
+	loginFailureTTLStr := os.Getenv("LOGIN_FAILURE_TTL")
+
+	if loginFailureTTLStr == "" {
+		loginFailureTTLStr = defaultLoginFailureTTL
+	}
+
+	loginFailureTTL, err := time.ParseDuration(loginFailureTTLStr)
+
+	if err != nil || loginFailureTTL <= 0 {
+		return nil, errors.New(domain.ErrParsingLoginFailureTTL)
+	}
+
+	// Without trusted proxies the client address is taken from the connection,
+	// the service has to be reached directly then.
+	trustedProxiesStr := os.Getenv("TRUSTED_PROXIES")
+
+	var trustedProxies []netip.Prefix
+
+	if trustedProxiesStr != "" {
+		for _, proxyStr := range strings.Split(trustedProxiesStr, ",") {
+			proxy, err := parseTrustedProxy(strings.TrimSpace(proxyStr))
+			if err != nil {
+				return nil, errors.New(domain.ErrParsingTrustedProxies)
+			}
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	rateLimitStore := os.Getenv("RATE_LIMIT_STORE")
+
+	if rateLimitStore == "" {
+		rateLimitStore = defaultRateLimitStore
+	}
+
+	if rateLimitStore != RateLimitStoreMemory && rateLimitStore != RateLimitStorePostgres {
+		return nil, errors.New(domain.ErrInvalidRateLimitStore)
+	}
+
+	defaultRateLimitStr := os.Getenv("RATE_LIMIT_DEFAULT")
+
+	if defaultRateLimitStr == "" {
+		defaultRateLimitStr = defaultRateLimitDefault
+	}
+
+	defaultRateLimit, err := ratelimit.ParseLimit(defaultRateLimitStr)
+
+	if err != nil {
+		return nil, errors.New(domain.ErrParsingDefaultRateLimit)
+	}
+
+	authRateLimitStr := os.Getenv("RATE_LIMIT_AUTH")
+
+	if authRateLimitStr == "" {
+		authRateLimitStr = defaultRateLimitAuth
+	}
+
+	authRateLimit, err := ratelimit.ParseLimit(authRateLimitStr)
+
+	if err != nil {
+		return nil, errors.New(domain.ErrParsingAuthRateLimit)
+	}
+
+	searchRateLimitStr := os.Getenv("RATE_LIMIT_SEARCH")
+
+	if searchRateLimitStr == "" {
+		searchRateLimitStr = defaultRateLimitSearch
+	}
+
+	searchRateLimit, err := ratelimit.ParseLimit(searchRateLimitStr)
+
+	if err != nil {
+		return nil, errors.New(domain.ErrParsingSearchRateLimit)
+	}
+
+	noteWriteRateLimitStr := os.Getenv("RATE_LIMIT_NOTE_WRITE")
+
+	if noteWriteRateLimitStr == "" {
+		noteWriteRateLimitStr = defaultRateLimitNoteWrite
+	}
+
+	noteWriteRateLimit, err := ratelimit.ParseLimit(noteWriteRateLimitStr)
+
+	if err != nil {
+		return nil, errors.New(domain.ErrParsingNoteWriteRateLimit)
+	}
+
 	spellerURL := os.Getenv("SPELLER_URL")
 
 	if spellerURL == "" {
 		return nil, errors.New("SPELLER_URL " + domain.ErrUndefinedEnvParam)
 	}
 
+	mailer := os.Getenv("MAILER")
+
+	if mailer == "" {
+		mailer = defaultMailer
+	}
+
+	if mailer != MailerSMTP && mailer != MailerFile && mailer != MailerLog {
+		return nil, errors.New(domain.ErrInvalidMailer)
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+
+	if mailFrom == "" {
+		mailFrom = defaultMailFrom
+	}
+
+	// SMTP credentials are optional, a local SMTP stand-in needs none.
+	smtpAddr := os.Getenv("SMTP_ADDR")
+	smtpUsername := os.Getenv("SMTP_USERNAME")
+	smtpPassword := os.Getenv("SMTP_PASSWORD")
+
+	if mailer == MailerSMTP && smtpAddr == "" {
+		return nil, errors.New("SMTP_ADDR " + domain.ErrUndefinedEnvParam)
+	}
+
+	mailFile := os.Getenv("MAIL_FILE")
+
+	if mailer == MailerFile && mailFile == "" {
+		return nil, errors.New("MAIL_FILE " + domain.ErrUndefinedEnvParam)
+	}
+
+	passwordResetTTLStr := os.Getenv("PASSWORD_RESET_TTL")
+
+	if passwordResetTTLStr == "" {
+		passwordResetTTLStr = defaultPasswordResetTTL
+	}
+
+	passwordResetTTL, err := time.ParseDuration(passwordResetTTLStr)
+
+	if err != nil || passwordResetTTL <= 0 {
+		return nil, errors.New(domain.ErrParsingPasswordResetTTL)
+	}
+
+	emailVerifyTTLStr := os.Getenv("EMAIL_VERIFY_TTL")
+
+	if emailVerifyTTLStr == "" {
+		emailVerifyTTLStr = defaultEmailVerifyTTL
+	}
+
+	emailVerifyTTL, err := time.ParseDuration(emailVerifyTTLStr)
+
+	if err != nil || emailVerifyTTL <= 0 {
+		return nil, errors.New(domain.ErrParsingEmailVerifyTTL)
+	}
+
+	trashRetentionStr := os.Getenv("TRASH_RETENTION")
+
+	if trashRetentionStr == "" {
+		trashRetentionStr = defaultTrashRetention
+	}
+
+	trashRetention, err := time.ParseDuration(trashRetentionStr)
+
+	if err != nil || trashRetention <= 0 {
+		return nil, errors.New(domain.ErrParsingTrashRetention)
+	}
+
+	requestTimeoutStr := os.Getenv("REQUEST_TIMEOUT")
+
+	if requestTimeoutStr == "" {
+		requestTimeoutStr = defaultRequestTimeout
+	}
+
+	requestTimeout, err := time.ParseDuration(requestTimeoutStr)
+
+	if err != nil || requestTimeout <= 0 {
+		return nil, errors.New(domain.ErrParsingRequestTimeout)
+	}
+
+	searchTimeoutStr := os.Getenv("SEARCH_TIMEOUT")
+
+	if searchTimeoutStr == "" {
+		searchTimeoutStr = defaultSearchTimeout
+	}
+
+	searchTimeout, err := time.ParseDuration(searchTimeoutStr)
+
+	if err != nil || searchTimeout <= 0 {
+		return nil, errors.New(domain.ErrParsingSearchTimeout)
+	}
+
+	noteWriteTimeoutStr := os.Getenv("NOTE_WRITE_TIMEOUT")
+
+	if noteWriteTimeoutStr == "" {
+		noteWriteTimeoutStr = defaultNoteWriteTimeout
+	}
+
+	noteWriteTimeout, err := time.ParseDuration(noteWriteTimeoutStr)
+
+	if err != nil || noteWriteTimeout <= 0 {
+		return nil, errors.New(domain.ErrParsingNoteWriteTimeout)
+	}
+
 	return &Config{
-		Port:              port,
-		DbUser:            dbUser,
-		DbPassword:        dbPassword,
-		DbHost:            dbHost,
-		DbPort:            dbPort,
-		DbName:            dbName,
-		AccessTTL:         accessTTL,
-		RefreshTTL:        refreshTTL,
-		AccessSigningKey:  accessSigningKey,
-		RefreshSigningKey: refreshSigningKey,
-		SpellerURL:        spellerURL,
+		Port:                 port,
+		DbUser:               dbUser,
+		DbPassword:           dbPassword,
+		DbHost:               dbHost,
+		DbPort:               dbPort,
+		DbName:               dbName,
+		AccessTTL:            accessTTL,
+		RefreshTTL:           refreshTTL,
+		AccessSigningKeys:    accessSigningKeys,
+		AccessSigningKey:     accessSigningKey,
+		RefreshSigningKey:    refreshSigningKey,
+		RefreshHashKey:       refreshHashKey,
+		Argon2Memory:         uint32(argon2Memory),
+		Argon2Iterations:     uint32(argon2Iterations),
+		Argon2Parallelism:    uint8(argon2Parallelism),
+		LoginMinLength:       loginMinLength,
+		LoginMaxLength:       loginMaxLength,
+		LoginAllowedSymbols:  loginAllowedSymbols,
+		PasswordMinLength:    passwordMinLength,
+		PasswordMaxLength:    passwordMaxLength,
+		PasswordMinClasses:   passwordMinClasses,
+		PasswordRejectLogin:  passwordRejectLogin,
+		PasswordRejectCommon: passwordRejectCommon,
+		TOTPIssuer:           totpIssuer,
+		TOTPSecretKey:        totpSecretKey,
+		TokenIssuer:          tokenIssuer,
+		TokenAudience:        tokenAudience,
+		TokenLeeway:          tokenLeeway,
+		RevocationStore:      revocationStore,
+		LoginLockoutStore:    loginLockoutStore,
+		LoginMaxFailures:     loginMaxFailures,
+		LoginIPMaxFailures:   loginIPMaxFailures,
+		LoginLockoutBase:     loginLockoutBase,
+		LoginLockoutMax:      loginLockoutMax,
+		LoginFailureTTL:      loginFailureTTL,
+		TrustedProxies:       trustedProxies,
+		RateLimitStore:       rateLimitStore,
+		DefaultRateLimit:     defaultRateLimit,
+		AuthRateLimit:        authRateLimit,
+		SearchRateLimit:      searchRateLimit,
+		NoteWriteRateLimit:   noteWriteRateLimit,
+		SpellerURL:           spellerURL,
+		Mailer:               mailer,
+		MailFrom:             mailFrom,
+		SMTPAddr:             smtpAddr,
+		SMTPUsername:         smtpUsername,
+		SMTPPassword:         smtpPassword,
+		MailFile:             mailFile,
+		PasswordResetTTL:     passwordResetTTL,
+		EmailVerifyTTL:       emailVerifyTTL,
+		TrashRetention:       trashRetention,
+		RequestTimeout:       requestTimeout,
+		SearchTimeout:        searchTimeout,
+		NoteWriteTimeout:     noteWriteTimeout,
 	}, nil
 }
+
+// parseTrustedProxy parses an IP address or a CIDR range of trusted proxies.
+func parseTrustedProxy(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
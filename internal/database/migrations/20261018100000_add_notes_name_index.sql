@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX notes_user_id_name_idx ON notes (user_id, name, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX notes_user_id_name_idx;
+-- +goose StatementEnd
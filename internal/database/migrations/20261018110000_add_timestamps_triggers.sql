@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX notes_user_id_created_at_idx ON notes (user_id, created_at, id);
+CREATE INDEX notes_user_id_updated_at_idx ON notes (user_id, updated_at, id);
+
+ALTER TABLE users
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD THEN
+        NEW.updated_at = now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_set_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- Moving a note to the trash or to another notebook doesn't change it.
+CREATE TRIGGER notes_set_updated_at
+    BEFORE UPDATE ON notes
+    FOR EACH ROW
+    WHEN ((OLD.name, OLD.content) IS DISTINCT FROM (NEW.name, NEW.content))
+    EXECUTE FUNCTION set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER notes_set_updated_at ON notes;
+DROP TRIGGER users_set_updated_at ON users;
+DROP FUNCTION set_updated_at();
+
+ALTER TABLE users
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at;
+
+DROP INDEX notes_user_id_updated_at_idx;
+DROP INDEX notes_user_id_created_at_idx;
+
+ALTER TABLE notes
+    DROP COLUMN updated_at,
+    DROP COLUMN created_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION notes_search_vector(name TEXT, content TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('russian'::regconfig, name), 'A') ||
+           setweight(to_tsvector('english'::regconfig, name), 'A') ||
+           setweight(to_tsvector('russian'::regconfig, content), 'B') ||
+           setweight(to_tsvector('english'::regconfig, content), 'B')
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE INDEX notes_search_idx ON notes USING GIN (notes_search_vector(name, content));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX notes_search_idx;
+DROP FUNCTION notes_search_vector(TEXT, TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notebooks (
+    id UUID DEFAULT uuid_generate_v4() NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL,
+    user_id UUID NOT NULL,
+    parent_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (parent_id) REFERENCES notebooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX notebooks_user_id_parent_id_idx ON notebooks (user_id, parent_id);
+
+CREATE TRIGGER notebooks_set_updated_at
+    BEFORE UPDATE ON notebooks
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+ALTER TABLE notes
+    ADD COLUMN notebook_id UUID REFERENCES notebooks(id) ON DELETE SET NULL;
+
+CREATE INDEX notes_notebook_id_idx ON notes (notebook_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX notes_notebook_id_idx;
+
+ALTER TABLE notes
+    DROP COLUMN notebook_id;
+
+DROP TABLE notebooks;
+-- +goose StatementEnd
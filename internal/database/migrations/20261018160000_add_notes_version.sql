@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes
+    ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE FUNCTION increment_version() RETURNS trigger AS $$
+BEGIN
+    IF NEW IS DISTINCT FROM OLD THEN
+        NEW.version = OLD.version + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER notes_increment_version
+    BEFORE UPDATE ON notes
+    FOR EACH ROW EXECUTE FUNCTION increment_version();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER notes_increment_version ON notes;
+DROP FUNCTION increment_version();
+
+ALTER TABLE notes
+    DROP COLUMN version;
+-- +goose StatementEnd
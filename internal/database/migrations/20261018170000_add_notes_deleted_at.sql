@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX notes_deleted_at_idx ON notes (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX notes_deleted_at_idx;
+
+ALTER TABLE notes
+    DROP COLUMN deleted_at;
+-- +goose StatementEnd
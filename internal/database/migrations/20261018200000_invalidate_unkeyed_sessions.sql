@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Refresh tokens used to be stored as plain SHA-256 digests. They can't be
+-- converted to HMAC without the tokens themselves, so the sessions are ended
+-- and users have to log in again.
+DELETE FROM sessions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM sessions;
+-- +goose StatementEnd
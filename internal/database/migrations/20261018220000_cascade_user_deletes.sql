@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notes
+    DROP CONSTRAINT notes_user_id_fkey,
+    ADD CONSTRAINT notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE tags
+    DROP CONSTRAINT tags_user_id_fkey,
+    ADD CONSTRAINT tags_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE notebooks
+    DROP CONSTRAINT notebooks_user_id_fkey,
+    ADD CONSTRAINT notebooks_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notebooks
+    DROP CONSTRAINT notebooks_user_id_fkey,
+    ADD CONSTRAINT notebooks_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE tags
+    DROP CONSTRAINT tags_user_id_fkey,
+    ADD CONSTRAINT tags_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE notes
+    DROP CONSTRAINT notes_user_id_fkey,
+    ADD CONSTRAINT notes_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN email TEXT,
+    ADD COLUMN email_verified_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX users_verified_email_idx ON users (lower(email)) WHERE email_verified_at IS NOT NULL;
+
+CREATE TABLE user_tokens (
+    token_hash TEXT NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    purpose TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX user_tokens_user_id_purpose_idx ON user_tokens (user_id, purpose);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_tokens;
+
+DROP INDEX users_verified_email_idx;
+
+ALTER TABLE users
+    DROP COLUMN email_verified_at,
+    DROP COLUMN email;
+-- +goose StatementEnd
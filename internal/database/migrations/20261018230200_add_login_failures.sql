@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE login_failures (
+    key TEXT NOT NULL PRIMARY KEY,
+    failures INT NOT NULL,
+    locked_until TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX login_failures_expires_at_idx ON login_failures (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE login_failures;
+-- +goose StatementEnd
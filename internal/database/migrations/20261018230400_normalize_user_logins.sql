@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+DO $$
+DECLARE
+    conflicts TEXT;
+BEGIN
+    SELECT string_agg(logins, '; ') INTO conflicts
+    FROM (
+        SELECT string_agg(login, ', ') AS logins
+        FROM users
+        GROUP BY lower(normalize(login, NFKC))
+        HAVING count(*) > 1
+    ) AS duplicates;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'logins that differ only in case or Unicode form have to be renamed first: %', conflicts;
+    END IF;
+END $$;
+
+UPDATE users
+SET login = normalize(login, NFKC)
+WHERE login <> normalize(login, NFKC);
+
+CREATE UNIQUE INDEX users_login_lower_idx ON users (lower(login));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_login_lower_idx;
+-- +goose StatementEnd
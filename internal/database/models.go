@@ -5,19 +5,128 @@
 package database
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/google/uuid"
 )
 
+type LoginChallenge struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Attempts  int32
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type LoginFailure struct {
+	Key         string
+	Failures    int32
+	LockedUntil sql.NullTime
+	ExpiresAt   time.Time
+}
+
 type Note struct {
-	ID      uuid.UUID
-	Name    string
-	Content string
-	UserID  uuid.UUID
+	ID         uuid.UUID
+	Name       string
+	Content    string
+	UserID     uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	NotebookID uuid.NullUUID
+	Version    int32
+	DeletedAt  sql.NullTime
+}
+
+type NoteRevision struct {
+	ID        uuid.UUID
+	NoteID    uuid.UUID
+	Revision  int32
+	Name      string
+	Content   string
+	CreatedAt time.Time
+}
+
+type NoteTag struct {
+	NoteID uuid.UUID
+	TagID  uuid.UUID
+}
+
+type Notebook struct {
+	ID        uuid.UUID
+	Name      string
+	UserID    uuid.UUID
+	ParentID  uuid.NullUUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RateLimit struct {
+	Key       string
+	Tokens    float64
+	Allowed   bool
+	UpdatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type RecoveryCode struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CodeHash  string
+	CreatedAt time.Time
+}
+
+type RevokedToken struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	SessionID uuid.NullUUID
+	Type      string
+	UserAgent string
+	Ip        string
+	CreatedAt time.Time
+}
+
+type Session struct {
+	ID               uuid.UUID
+	UserID           uuid.UUID
+	RefreshTokenHash string
+	UserAgent        string
+	Ip               string
+	CreatedAt        time.Time
+	LastUsedAt       time.Time
+	AccessTokenID    string
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	Name      string
+	UserID    uuid.UUID
+	CreatedAt time.Time
 }
 
 type User struct {
-	ID           uuid.UUID
-	Login        string
-	Password     string
-	RefreshToken string
+	ID              uuid.UUID
+	Login           string
+	Password        string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Email           sql.NullString
+	EmailVerifiedAt sql.NullTime
+	TotpSecret      sql.NullString
+	TotpEnabledAt   sql.NullTime
+	TotpLastStep    int64
+}
+
+type UserToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Purpose   string
+	Email     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
 }
@@ -7,14 +7,17 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createNote = `-- name: CreateNote :one
 INSERT INTO notes (name, content, user_id)
 VALUES ($1, $2, $3)
-RETURNING id, name, content
+RETURNING id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at
 `
 
 type CreateNoteParams struct {
@@ -23,40 +26,416 @@ type CreateNoteParams struct {
 	UserID  uuid.UUID
 }
 
-type CreateNoteRow struct {
-	ID      uuid.UUID
-	Name    string
-	Content string
+func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, createNote, arg.Name, arg.Content, arg.UserID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
-func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) (CreateNoteRow, error) {
-	row := q.db.QueryRowContext(ctx, createNote, arg.Name, arg.Content, arg.UserID)
-	var i CreateNoteRow
-	err := row.Scan(&i.ID, &i.Name, &i.Content)
+const deleteNote = `-- name: DeleteNote :execrows
+UPDATE notes
+SET deleted_at = now()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+`
+
+type DeleteNoteParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteNote(ctx context.Context, arg DeleteNoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteNote, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const emptyTrash = `-- name: EmptyTrash :execrows
+DELETE FROM notes
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) EmptyTrash(ctx context.Context, userID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, emptyTrash, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getNote = `-- name: GetNote :one
+SELECT id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at FROM notes
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+`
+
+type GetNoteParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetNote(ctx context.Context, arg GetNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, getNote, arg.ID, arg.UserID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
 	return i, err
 }
 
-const getNotes = `-- name: GetNotes :many
-SELECT id, name, content FROM notes
+const getNoteForUpdate = `-- name: GetNoteForUpdate :one
+SELECT id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at FROM notes
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type GetNoteForUpdateParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetNoteForUpdate(ctx context.Context, arg GetNoteForUpdateParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, getNoteForUpdate, arg.ID, arg.UserID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const listDeletedNotes = `-- name: ListDeletedNotes :many
+SELECT id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at FROM notes
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+`
+
+func (q *Queries) ListDeletedNotes(ctx context.Context, userID uuid.UUID) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedNotes, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Content,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNotes = `-- name: ListNotes :many
+SELECT id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at FROM notes
 WHERE user_id = $1
+  AND deleted_at IS NULL
+  AND (
+    NOT $2::boolean
+    OR ($3::text = 'name' AND $4::text = 'asc' AND (name, id) > ($5::text, $6::uuid))
+    OR ($3::text = 'name' AND $4::text = 'desc' AND (name, id) < ($5::text, $6::uuid))
+    OR ($3::text = 'created_at' AND $4::text = 'asc' AND (created_at, id) > ($7::timestamptz, $6::uuid))
+    OR ($3::text = 'created_at' AND $4::text = 'desc' AND (created_at, id) < ($7::timestamptz, $6::uuid))
+    OR ($3::text = 'updated_at' AND $4::text = 'asc' AND (updated_at, id) > ($7::timestamptz, $6::uuid))
+    OR ($3::text = 'updated_at' AND $4::text = 'desc' AND (updated_at, id) < ($7::timestamptz, $6::uuid))
+  )
+  AND ($8::timestamptz IS NULL OR updated_at >= $8::timestamptz)
+  AND ($9::uuid IS NULL OR notebook_id = $9::uuid)
+  AND (
+    COALESCE(cardinality($10::text[]), 0) = 0
+    OR ($11::text = 'any' AND EXISTS (
+        SELECT 1 FROM note_tags
+        JOIN tags ON tags.id = note_tags.tag_id
+        WHERE note_tags.note_id = notes.id AND tags.name = ANY($10::text[])
+    ))
+    OR ($11::text = 'all' AND (
+        SELECT COUNT(DISTINCT tags.name) FROM note_tags
+        JOIN tags ON tags.id = note_tags.tag_id
+        WHERE note_tags.note_id = notes.id AND tags.name = ANY($10::text[])
+    ) = cardinality($10::text[]))
+  )
+ORDER BY
+    CASE WHEN $3::text = 'name' AND $4::text = 'asc' THEN name END ASC,
+    CASE WHEN $3::text = 'name' AND $4::text = 'desc' THEN name END DESC,
+    CASE WHEN $3::text = 'created_at' AND $4::text = 'asc' THEN created_at END ASC,
+    CASE WHEN $3::text = 'created_at' AND $4::text = 'desc' THEN created_at END DESC,
+    CASE WHEN $3::text = 'updated_at' AND $4::text = 'asc' THEN updated_at END ASC,
+    CASE WHEN $3::text = 'updated_at' AND $4::text = 'desc' THEN updated_at END DESC,
+    CASE WHEN $4::text = 'asc' THEN id END ASC,
+    CASE WHEN $4::text = 'desc' THEN id END DESC
+LIMIT $12
 `
 
-type GetNotesRow struct {
+type ListNotesParams struct {
+	UserID       uuid.UUID
+	HasCursor    bool
+	SortBy       string
+	SortOrder    string
+	CursorName   string
+	CursorID     uuid.UUID
+	CursorTime   time.Time
+	UpdatedSince sql.NullTime
+	NotebookID   uuid.NullUUID
+	Tags         []string
+	TagMode      string
+	RowLimit     int32
+}
+
+func (q *Queries) ListNotes(ctx context.Context, arg ListNotesParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, listNotes,
+		arg.UserID,
+		arg.HasCursor,
+		arg.SortBy,
+		arg.SortOrder,
+		arg.CursorName,
+		arg.CursorID,
+		arg.CursorTime,
+		arg.UpdatedSince,
+		arg.NotebookID,
+		pq.Array(arg.Tags),
+		arg.TagMode,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Content,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.NotebookID,
+			&i.Version,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveNote = `-- name: MoveNote :one
+UPDATE notes
+SET notebook_id = $1
+WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+RETURNING id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at
+`
+
+type MoveNoteParams struct {
+	NotebookID uuid.NullUUID
+	ID         uuid.UUID
+	UserID     uuid.UUID
+}
+
+func (q *Queries) MoveNote(ctx context.Context, arg MoveNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, moveNote, arg.NotebookID, arg.ID, arg.UserID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const patchNote = `-- name: PatchNote :one
+UPDATE notes
+SET name = COALESCE($1, name),
+    content = COALESCE($2, content),
+    version = version + 1
+WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL
+RETURNING id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at
+`
+
+type PatchNoteParams struct {
+	Name    sql.NullString
+	Content sql.NullString
 	ID      uuid.UUID
-	Name    string
-	Content string
+	UserID  uuid.UUID
+}
+
+func (q *Queries) PatchNote(ctx context.Context, arg PatchNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, patchNote,
+		arg.Name,
+		arg.Content,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const purgeDeletedNotes = `-- name: PurgeDeletedNotes :execrows
+DELETE FROM notes
+WHERE deleted_at < $1::timestamptz
+`
+
+func (q *Queries) PurgeDeletedNotes(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedNotes, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreDeletedNote = `-- name: RestoreDeletedNote :one
+UPDATE notes
+SET deleted_at = NULL
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+RETURNING id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at
+`
+
+type RestoreDeletedNoteParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
 }
 
-func (q *Queries) GetNotes(ctx context.Context, userID uuid.UUID) ([]GetNotesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getNotes, userID)
+func (q *Queries) RestoreDeletedNote(ctx context.Context, arg RestoreDeletedNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, restoreDeletedNote, arg.ID, arg.UserID)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const searchNotes = `-- name: SearchNotes :many
+WITH search AS (
+    SELECT websearch_to_tsquery('russian', $1::text) || websearch_to_tsquery('english', $1::text) AS query
+)
+SELECT notes.id, notes.name, notes.created_at, notes.updated_at,
+       ts_rank(notes_search_vector(notes.name, notes.content), search.query)::real AS rank,
+       ts_headline('russian', notes.name, search.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS name_headline,
+       ts_headline('russian', notes.content, search.query, 'MaxFragments=3, MaxWords=35, MinWords=15, FragmentDelimiter=" … ", StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM notes, search
+WHERE notes.user_id = $2
+  AND notes.deleted_at IS NULL
+  AND notes_search_vector(notes.name, notes.content) @@ search.query
+ORDER BY rank DESC, notes.id
+LIMIT $3 OFFSET $4
+`
+
+type SearchNotesParams struct {
+	Query     string
+	UserID    uuid.UUID
+	RowLimit  int32
+	RowOffset int32
+}
+
+type SearchNotesRow struct {
+	ID           uuid.UUID
+	Name         string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Rank         float32
+	NameHeadline string
+	Snippet      string
+}
+
+func (q *Queries) SearchNotes(ctx context.Context, arg SearchNotesParams) ([]SearchNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchNotes,
+		arg.Query,
+		arg.UserID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetNotesRow
+	var items []SearchNotesRow
 	for rows.Next() {
-		var i GetNotesRow
-		if err := rows.Scan(&i.ID, &i.Name, &i.Content); err != nil {
+		var i SearchNotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Rank,
+			&i.NameHeadline,
+			&i.Snippet,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -69,3 +448,39 @@ func (q *Queries) GetNotes(ctx context.Context, userID uuid.UUID) ([]GetNotesRow
 	}
 	return items, nil
 }
+
+const updateNote = `-- name: UpdateNote :one
+UPDATE notes
+SET name = $3, content = $4, version = version + 1
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING id, name, content, user_id, created_at, updated_at, notebook_id, version, deleted_at
+`
+
+type UpdateNoteParams struct {
+	ID      uuid.UUID
+	UserID  uuid.UUID
+	Name    string
+	Content string
+}
+
+func (q *Queries) UpdateNote(ctx context.Context, arg UpdateNoteParams) (Note, error) {
+	row := q.db.QueryRowContext(ctx, updateNote,
+		arg.ID,
+		arg.UserID,
+		arg.Name,
+		arg.Content,
+	)
+	var i Note
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Content,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.NotebookID,
+		&i.Version,
+		&i.DeletedAt,
+	)
+	return i, err
+}
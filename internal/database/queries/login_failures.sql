@@ -0,0 +1,27 @@
+-- name: GetLoginLockout :one
+SELECT locked_until
+FROM login_failures
+WHERE key = $1 AND expires_at > now();
+
+-- name: AddLoginFailure :one
+INSERT INTO login_failures (key, failures, expires_at)
+VALUES ($1, 1, $2)
+ON CONFLICT (key) DO UPDATE SET
+    failures = CASE WHEN login_failures.expires_at > now() THEN login_failures.failures + 1 ELSE 1 END,
+    locked_until = CASE WHEN login_failures.expires_at > now() THEN login_failures.locked_until END,
+    expires_at = CASE WHEN login_failures.expires_at > now() THEN GREATEST(login_failures.expires_at, EXCLUDED.expires_at) ELSE EXCLUDED.expires_at END
+RETURNING failures;
+
+-- name: LockLoginFailures :exec
+UPDATE login_failures
+SET locked_until = GREATEST(locked_until, sqlc.arg(locked_until)::timestamptz),
+    expires_at = GREATEST(expires_at, sqlc.arg(locked_until)::timestamptz)
+WHERE key = sqlc.arg(key);
+
+-- name: DeleteLoginFailures :exec
+DELETE FROM login_failures
+WHERE key = $1;
+
+-- name: DeleteExpiredLoginFailures :execrows
+DELETE FROM login_failures
+WHERE expires_at <= now();
@@ -0,0 +1,61 @@
+-- name: CreateNotebook :one
+INSERT INTO notebooks (name, user_id, parent_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListNotebooks :many
+SELECT * FROM notebooks
+WHERE user_id = $1
+ORDER BY name, id;
+
+-- name: GetNotebook :one
+SELECT * FROM notebooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateNotebook :one
+UPDATE notebooks
+SET name = $3, parent_id = $4
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: LockNotebookChain :exec
+WITH RECURSIVE chain AS (
+    SELECT notebooks.id, notebooks.parent_id FROM notebooks
+    WHERE notebooks.id = sqlc.arg(parent_id)::uuid
+    UNION
+    SELECT notebooks.id, notebooks.parent_id FROM notebooks
+    JOIN chain ON notebooks.id = chain.parent_id
+)
+SELECT notebooks.id FROM notebooks
+WHERE notebooks.id IN (SELECT chain.id FROM chain) OR notebooks.id = sqlc.arg(notebook_id)::uuid
+ORDER BY notebooks.id
+FOR UPDATE;
+
+-- name: IsNotebookInSubtree :one
+WITH RECURSIVE subtree AS (
+    SELECT notebooks.id FROM notebooks
+    WHERE notebooks.id = sqlc.arg(root_id)::uuid
+    UNION
+    SELECT notebooks.id FROM notebooks
+    JOIN subtree ON notebooks.parent_id = subtree.id
+)
+SELECT EXISTS (
+    SELECT 1 FROM subtree
+    WHERE subtree.id = sqlc.arg(notebook_id)::uuid
+) AS in_subtree;
+
+-- name: TrashNotebookSubtreeNotes :exec
+WITH RECURSIVE subtree AS (
+    SELECT notebooks.id FROM notebooks
+    WHERE notebooks.id = sqlc.arg(root_id)::uuid AND notebooks.user_id = sqlc.arg(user_id)::uuid
+    UNION
+    SELECT notebooks.id FROM notebooks
+    JOIN subtree ON notebooks.parent_id = subtree.id
+)
+UPDATE notes
+SET deleted_at = now()
+WHERE notebook_id IN (SELECT subtree.id FROM subtree) AND deleted_at IS NULL;
+
+-- name: DeleteNotebook :execrows
+DELETE FROM notebooks
+WHERE id = $1 AND user_id = $2;
@@ -1,8 +1,111 @@
 -- name: CreateNote :one
 INSERT INTO notes (name, content, user_id)
 VALUES ($1, $2, $3)
-RETURNING id, name, content;
+RETURNING *;
 
--- name: GetNotes :many
-SELECT id, name, content FROM notes
-WHERE user_id = $1;
\ No newline at end of file
+-- name: ListNotes :many
+SELECT * FROM notes
+WHERE user_id = sqlc.arg(user_id)
+  AND deleted_at IS NULL
+  AND (
+    NOT sqlc.arg(has_cursor)::boolean
+    OR (sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_order)::text = 'asc' AND (name, id) > (sqlc.arg(cursor_name)::text, sqlc.arg(cursor_id)::uuid))
+    OR (sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_order)::text = 'desc' AND (name, id) < (sqlc.arg(cursor_name)::text, sqlc.arg(cursor_id)::uuid))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_order)::text = 'asc' AND (created_at, id) > (sqlc.arg(cursor_time)::timestamptz, sqlc.arg(cursor_id)::uuid))
+    OR (sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_order)::text = 'desc' AND (created_at, id) < (sqlc.arg(cursor_time)::timestamptz, sqlc.arg(cursor_id)::uuid))
+    OR (sqlc.arg(sort_by)::text = 'updated_at' AND sqlc.arg(sort_order)::text = 'asc' AND (updated_at, id) > (sqlc.arg(cursor_time)::timestamptz, sqlc.arg(cursor_id)::uuid))
+    OR (sqlc.arg(sort_by)::text = 'updated_at' AND sqlc.arg(sort_order)::text = 'desc' AND (updated_at, id) < (sqlc.arg(cursor_time)::timestamptz, sqlc.arg(cursor_id)::uuid))
+  )
+  AND (sqlc.narg(updated_since)::timestamptz IS NULL OR updated_at >= sqlc.narg(updated_since)::timestamptz)
+  AND (sqlc.narg(notebook_id)::uuid IS NULL OR notebook_id = sqlc.narg(notebook_id)::uuid)
+  AND (
+    COALESCE(cardinality(sqlc.arg(tags)::text[]), 0) = 0
+    OR (sqlc.arg(tag_mode)::text = 'any' AND EXISTS (
+        SELECT 1 FROM note_tags
+        JOIN tags ON tags.id = note_tags.tag_id
+        WHERE note_tags.note_id = notes.id AND tags.name = ANY(sqlc.arg(tags)::text[])
+    ))
+    OR (sqlc.arg(tag_mode)::text = 'all' AND (
+        SELECT COUNT(DISTINCT tags.name) FROM note_tags
+        JOIN tags ON tags.id = note_tags.tag_id
+        WHERE note_tags.note_id = notes.id AND tags.name = ANY(sqlc.arg(tags)::text[])
+    ) = cardinality(sqlc.arg(tags)::text[]))
+  )
+ORDER BY
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_order)::text = 'asc' THEN name END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'name' AND sqlc.arg(sort_order)::text = 'desc' THEN name END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_order)::text = 'asc' THEN created_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_order)::text = 'desc' THEN created_at END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'updated_at' AND sqlc.arg(sort_order)::text = 'asc' THEN updated_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'updated_at' AND sqlc.arg(sort_order)::text = 'desc' THEN updated_at END DESC,
+    CASE WHEN sqlc.arg(sort_order)::text = 'asc' THEN id END ASC,
+    CASE WHEN sqlc.arg(sort_order)::text = 'desc' THEN id END DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetNote :one
+SELECT * FROM notes
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+
+-- name: UpdateNote :one
+UPDATE notes
+SET name = $3, content = $4, version = version + 1
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: PatchNote :one
+UPDATE notes
+SET name = COALESCE(sqlc.narg(name), name),
+    content = COALESCE(sqlc.narg(content), content),
+    version = version + 1
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: MoveNote :one
+UPDATE notes
+SET notebook_id = sqlc.narg(notebook_id)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id) AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteNote :execrows
+UPDATE notes
+SET deleted_at = now()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
+
+-- name: SearchNotes :many
+WITH search AS (
+    SELECT websearch_to_tsquery('russian', sqlc.arg(query)::text) || websearch_to_tsquery('english', sqlc.arg(query)::text) AS query
+)
+SELECT notes.id, notes.name, notes.created_at, notes.updated_at,
+       ts_rank(notes_search_vector(notes.name, notes.content), search.query)::real AS rank,
+       ts_headline('russian', notes.name, search.query, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>')::text AS name_headline,
+       ts_headline('russian', notes.content, search.query, 'MaxFragments=3, MaxWords=35, MinWords=15, FragmentDelimiter=" … ", StartSel=<mark>, StopSel=</mark>')::text AS snippet
+FROM notes, search
+WHERE notes.user_id = sqlc.arg(user_id)
+  AND notes.deleted_at IS NULL
+  AND notes_search_vector(notes.name, notes.content) @@ search.query
+ORDER BY rank DESC, notes.id
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: GetNoteForUpdate :one
+SELECT * FROM notes
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ListDeletedNotes :many
+SELECT * FROM notes
+WHERE user_id = $1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id;
+
+-- name: RestoreDeletedNote :one
+UPDATE notes
+SET deleted_at = NULL
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+RETURNING *;
+
+-- name: EmptyTrash :execrows
+DELETE FROM notes
+WHERE user_id = $1 AND deleted_at IS NOT NULL;
+
+-- name: PurgeDeletedNotes :execrows
+DELETE FROM notes
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz;
\ No newline at end of file
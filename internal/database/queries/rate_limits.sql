@@ -0,0 +1,23 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limits AS b (key, tokens, allowed, updated_at, expires_at)
+VALUES (
+    sqlc.arg(key),
+    sqlc.arg(requests)::float8 - 1,
+    TRUE,
+    now(),
+    now() + make_interval(secs => sqlc.arg(period_seconds)::float8)
+)
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST(sqlc.arg(requests)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) >= 1
+        THEN LEAST(sqlc.arg(requests)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) - 1
+        ELSE LEAST(sqlc.arg(requests)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8)
+    END,
+    allowed = LEAST(sqlc.arg(requests)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at)::float8 * sqlc.arg(rate)::float8) >= 1,
+    updated_at = now(),
+    expires_at = now() + make_interval(secs => sqlc.arg(period_seconds)::float8)
+RETURNING tokens, allowed;
+
+-- name: DeleteExpiredRateLimits :execrows
+DELETE FROM rate_limits
+WHERE expires_at <= now();
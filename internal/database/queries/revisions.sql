@@ -0,0 +1,15 @@
+-- name: CreateNoteRevision :one
+INSERT INTO note_revisions (note_id, revision, name, content)
+SELECT sqlc.arg(note_id)::uuid, COALESCE(MAX(revision), 0) + 1, sqlc.arg(name)::text, sqlc.arg(content)::text
+FROM note_revisions
+WHERE note_id = sqlc.arg(note_id)
+RETURNING *;
+
+-- name: ListNoteRevisions :many
+SELECT revision, name, created_at FROM note_revisions
+WHERE note_id = $1
+ORDER BY revision DESC;
+
+-- name: GetNoteRevision :one
+SELECT * FROM note_revisions
+WHERE note_id = $1 AND revision = $2;
@@ -0,0 +1,15 @@
+-- name: RevokeToken :exec
+INSERT INTO revoked_tokens (id, expires_at)
+VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE SET expires_at = GREATEST(revoked_tokens.expires_at, EXCLUDED.expires_at);
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+    SELECT 1
+    FROM revoked_tokens
+    WHERE id = $1 AND expires_at > now()
+) AS revoked;
+
+-- name: DeleteExpiredRevokedTokens :execrows
+DELETE FROM revoked_tokens
+WHERE expires_at <= now();
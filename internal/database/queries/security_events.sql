@@ -0,0 +1,3 @@
+-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (user_id, session_id, type, user_agent, ip)
+VALUES ($1, $2, $3, $4, $5);
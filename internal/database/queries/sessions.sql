@@ -0,0 +1,37 @@
+-- name: CreateSession :one
+INSERT INTO sessions (id, user_id, refresh_token_hash, access_token_id, user_agent, ip)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetSession :one
+SELECT * FROM sessions
+WHERE id = $1 AND user_id = $2;
+
+-- name: RotateSession :execrows
+UPDATE sessions
+SET refresh_token_hash = sqlc.arg(new_refresh_token_hash), access_token_id = sqlc.arg(access_token_id), user_agent = sqlc.arg(user_agent), ip = sqlc.arg(ip), last_used_at = now()
+WHERE id = sqlc.arg(id) AND refresh_token_hash = sqlc.arg(refresh_token_hash);
+
+-- name: ListSessions :many
+SELECT * FROM sessions
+WHERE user_id = sqlc.arg(user_id) AND last_used_at > sqlc.arg(active_since)
+ORDER BY last_used_at DESC;
+
+-- name: DeleteSession :one
+DELETE FROM sessions
+WHERE id = $1 AND user_id = $2
+RETURNING access_token_id;
+
+-- name: DeleteUserSessions :many
+DELETE FROM sessions
+WHERE user_id = $1
+RETURNING access_token_id;
+
+-- name: DeleteOtherUserSessions :many
+DELETE FROM sessions
+WHERE user_id = $1 AND id <> $2
+RETURNING access_token_id;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions
+WHERE user_id = sqlc.arg(user_id) AND last_used_at <= sqlc.arg(active_since);
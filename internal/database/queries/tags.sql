@@ -0,0 +1,72 @@
+-- name: UpsertTags :many
+INSERT INTO tags (user_id, name)
+SELECT sqlc.arg(user_id), unnest(sqlc.arg(names)::text[])
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: DeleteNoteTags :exec
+DELETE FROM note_tags
+WHERE note_id = $1;
+
+-- name: AddNoteTags :exec
+INSERT INTO note_tags (note_id, tag_id)
+SELECT sqlc.arg(note_id), unnest(sqlc.arg(tag_ids)::uuid[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetNoteTags :many
+SELECT tags.name
+FROM tags
+JOIN note_tags ON note_tags.tag_id = tags.id
+WHERE note_tags.note_id = $1
+ORDER BY tags.name;
+
+-- name: GetNotesTags :many
+SELECT note_tags.note_id, tags.name
+FROM tags
+JOIN note_tags ON note_tags.tag_id = tags.id
+WHERE note_tags.note_id = ANY(sqlc.arg(note_ids)::uuid[])
+ORDER BY tags.name;
+
+-- name: ListTags :many
+SELECT tags.id, tags.name, COUNT(notes.id) AS notes_count
+FROM tags
+LEFT JOIN note_tags ON note_tags.tag_id = tags.id
+LEFT JOIN notes ON notes.id = note_tags.note_id AND notes.deleted_at IS NULL
+WHERE tags.user_id = $1
+GROUP BY tags.id
+ORDER BY tags.name;
+
+-- name: GetTag :one
+SELECT * FROM tags
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetTagByName :one
+SELECT * FROM tags
+WHERE user_id = $1 AND name = $2;
+
+-- name: CountTagNotes :one
+SELECT COUNT(*) FROM note_tags
+JOIN notes ON notes.id = note_tags.note_id
+WHERE note_tags.tag_id = $1 AND notes.deleted_at IS NULL;
+
+-- name: RenameTag :one
+UPDATE tags
+SET name = $3
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: MergeTagNotes :exec
+INSERT INTO note_tags (note_id, tag_id)
+SELECT note_id, sqlc.arg(target_id)::uuid
+FROM note_tags
+WHERE tag_id = sqlc.arg(source_id)::uuid
+ON CONFLICT DO NOTHING;
+
+-- name: TouchTagNotes :exec
+UPDATE notes
+SET version = version + 1
+WHERE user_id = $2 AND id IN (SELECT note_id FROM note_tags WHERE tag_id = $1);
+
+-- name: DeleteTag :execrows
+DELETE FROM tags
+WHERE id = $1 AND user_id = $2;
@@ -0,0 +1,60 @@
+-- name: GetUserTOTPForUpdate :one
+SELECT totp_secret, totp_enabled_at, totp_last_step
+FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SetUserTOTPSecret :exec
+UPDATE users
+SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = 0
+WHERE id = $1;
+
+-- name: EnableUserTOTP :exec
+UPDATE users
+SET totp_enabled_at = now(), totp_last_step = $2
+WHERE id = $1;
+
+-- name: SetUserTOTPLastStep :exec
+UPDATE users
+SET totp_last_step = $2
+WHERE id = $1;
+
+-- name: DisableUserTOTP :exec
+UPDATE users
+SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0
+WHERE id = $1;
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO recovery_codes (user_id, code_hash)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(code_hashes)::text[]);
+
+-- name: DeleteRecoveryCode :execrows
+DELETE FROM recovery_codes
+WHERE user_id = $1 AND code_hash = $2;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateLoginChallenge :exec
+INSERT INTO login_challenges (token_hash, user_id, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetLoginChallengeForUpdate :one
+SELECT * FROM login_challenges
+WHERE token_hash = $1 AND expires_at > now()
+FOR UPDATE;
+
+-- name: IncrementLoginChallengeAttempts :one
+UPDATE login_challenges
+SET attempts = attempts + 1
+WHERE token_hash = $1
+RETURNING attempts;
+
+-- name: DeleteLoginChallenge :exec
+DELETE FROM login_challenges
+WHERE token_hash = $1;
+
+-- name: DeleteExpiredLoginChallenges :exec
+DELETE FROM login_challenges
+WHERE user_id = $1 AND expires_at <= now();
@@ -0,0 +1,12 @@
+-- name: CreateUserToken :exec
+INSERT INTO user_tokens (token_hash, user_id, purpose, email, expires_at)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeUserToken :one
+DELETE FROM user_tokens
+WHERE token_hash = $1 AND purpose = $2 AND expires_at > now()
+RETURNING user_id, email;
+
+-- name: DeleteUserTokens :exec
+DELETE FROM user_tokens
+WHERE user_id = $1 AND purpose = $2;
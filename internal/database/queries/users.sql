@@ -2,7 +2,7 @@
 SELECT EXISTS (
     SELECT 1
     FROM users
-    WHERE login = $1
+    WHERE lower(login) = lower(sqlc.arg(login))
 ) AS user_exist;
 
 -- name: CreateUser :one
@@ -10,22 +10,46 @@ INSERT INTO users (login, password)
 VALUES ($1, $2)
 RETURNING id;
 
--- name: SaveRefreshToken :exec
+-- name: GetUserByLogin :one
+SELECT id, password, totp_enabled_at
+FROM users
+WHERE lower(login) = lower(sqlc.arg(login));
+
+-- name: GetUserPassword :one
+SELECT login, password
+FROM users
+WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
 UPDATE users
-SET refresh_token = $2
+SET password = $2
 WHERE id = $1;
 
--- name: GetUserByLogin :one
-SELECT id, password
+-- name: RehashUserPassword :exec
+UPDATE users
+SET password = sqlc.arg(new_password)
+WHERE id = sqlc.arg(id) AND password = sqlc.arg(old_password);
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
+
+-- name: GetUser :one
+SELECT id, login, email, email_verified_at, totp_enabled_at
+FROM users
+WHERE id = $1;
+
+-- name: GetUserByVerifiedEmail :one
+SELECT id, email
 FROM users
-WHERE login = $1;
+WHERE lower(email) = lower(sqlc.arg(email)) AND email_verified_at IS NOT NULL;
 
--- name: Logout :exec
+-- name: SetUserEmail :exec
 UPDATE users
-SET refresh_token = ''
+SET email = $2, email_verified_at = NULL
 WHERE id = $1;
 
--- name: GetRefreshTokenById :one
-SELECT refresh_token
-FROM users
-WHERE id = $1;
\ No newline at end of file
+-- name: VerifyUserEmail :execrows
+UPDATE users
+SET email_verified_at = now()
+WHERE id = $1 AND email = $2 AND email_verified_at IS NULL;
\ No newline at end of file
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -15,7 +16,7 @@ const checkUserExist = `-- name: CheckUserExist :one
 SELECT EXISTS (
     SELECT 1
     FROM users
-    WHERE login = $1
+    WHERE lower(login) = lower($1)
 ) AS user_exist
 `
 
@@ -44,60 +45,162 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (uuid.UU
 	return id, err
 }
 
-const getRefreshTokenById = `-- name: GetRefreshTokenById :one
-SELECT refresh_token
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, id)
+	return err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, login, email, email_verified_at, totp_enabled_at
 FROM users
 WHERE id = $1
 `
 
-func (q *Queries) GetRefreshTokenById(ctx context.Context, id uuid.UUID) (string, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshTokenById, id)
-	var refresh_token string
-	err := row.Scan(&refresh_token)
-	return refresh_token, err
+type GetUserRow struct {
+	ID              uuid.UUID
+	Login           string
+	Email           sql.NullString
+	EmailVerifiedAt sql.NullTime
+	TotpEnabledAt   sql.NullTime
+}
+
+func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (GetUserRow, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i GetUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.Login,
+		&i.Email,
+		&i.EmailVerifiedAt,
+		&i.TotpEnabledAt,
+	)
+	return i, err
 }
 
 const getUserByLogin = `-- name: GetUserByLogin :one
-SELECT id, password
+SELECT id, password, totp_enabled_at
 FROM users
-WHERE login = $1
+WHERE lower(login) = lower($1)
 `
 
 type GetUserByLoginRow struct {
-	ID       uuid.UUID
-	Password string
+	ID            uuid.UUID
+	Password      string
+	TotpEnabledAt sql.NullTime
 }
 
 func (q *Queries) GetUserByLogin(ctx context.Context, login string) (GetUserByLoginRow, error) {
 	row := q.db.QueryRowContext(ctx, getUserByLogin, login)
 	var i GetUserByLoginRow
-	err := row.Scan(&i.ID, &i.Password)
+	err := row.Scan(&i.ID, &i.Password, &i.TotpEnabledAt)
 	return i, err
 }
 
-const logout = `-- name: Logout :exec
+const getUserByVerifiedEmail = `-- name: GetUserByVerifiedEmail :one
+SELECT id, email
+FROM users
+WHERE lower(email) = lower($1) AND email_verified_at IS NOT NULL
+`
+
+type GetUserByVerifiedEmailRow struct {
+	ID    uuid.UUID
+	Email sql.NullString
+}
+
+func (q *Queries) GetUserByVerifiedEmail(ctx context.Context, email string) (GetUserByVerifiedEmailRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserByVerifiedEmail, email)
+	var i GetUserByVerifiedEmailRow
+	err := row.Scan(&i.ID, &i.Email)
+	return i, err
+}
+
+const getUserPassword = `-- name: GetUserPassword :one
+SELECT login, password
+FROM users
+WHERE id = $1
+`
+
+type GetUserPasswordRow struct {
+	Login    string
+	Password string
+}
+
+func (q *Queries) GetUserPassword(ctx context.Context, id uuid.UUID) (GetUserPasswordRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserPassword, id)
+	var i GetUserPasswordRow
+	err := row.Scan(&i.Login, &i.Password)
+	return i, err
+}
+
+const rehashUserPassword = `-- name: RehashUserPassword :exec
+UPDATE users
+SET password = $1
+WHERE id = $2 AND password = $3
+`
+
+type RehashUserPasswordParams struct {
+	NewPassword string
+	ID          uuid.UUID
+	OldPassword string
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, rehashUserPassword, arg.NewPassword, arg.ID, arg.OldPassword)
+	return err
+}
+
+const setUserEmail = `-- name: SetUserEmail :exec
 UPDATE users
-SET refresh_token = ''
+SET email = $2, email_verified_at = NULL
 WHERE id = $1
 `
 
-func (q *Queries) Logout(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, logout, id)
+type SetUserEmailParams struct {
+	ID    uuid.UUID
+	Email sql.NullString
+}
+
+func (q *Queries) SetUserEmail(ctx context.Context, arg SetUserEmailParams) error {
+	_, err := q.db.ExecContext(ctx, setUserEmail, arg.ID, arg.Email)
 	return err
 }
 
-const saveRefreshToken = `-- name: SaveRefreshToken :exec
+const updateUserPassword = `-- name: UpdateUserPassword :exec
 UPDATE users
-SET refresh_token = $2
+SET password = $2
 WHERE id = $1
 `
 
-type SaveRefreshTokenParams struct {
-	ID           uuid.UUID
-	RefreshToken string
+type UpdateUserPasswordParams struct {
+	ID       uuid.UUID
+	Password string
 }
 
-func (q *Queries) SaveRefreshToken(ctx context.Context, arg SaveRefreshTokenParams) error {
-	_, err := q.db.ExecContext(ctx, saveRefreshToken, arg.ID, arg.RefreshToken)
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.Password)
 	return err
 }
+
+const verifyUserEmail = `-- name: VerifyUserEmail :execrows
+UPDATE users
+SET email_verified_at = now()
+WHERE id = $1 AND email = $2 AND email_verified_at IS NULL
+`
+
+type VerifyUserEmailParams struct {
+	ID    uuid.UUID
+	Email sql.NullString
+}
+
+func (q *Queries) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, verifyUserEmail, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,19 @@
+package delivery
+
+import (
+	"net"
+	"net/http"
+	"notes-service-go/internal/domain"
+)
+
+// ClientFromRequest returns the user agent and the IP address of the client
+// that made the request. Requests made through trusted proxies get the address
+// of the client from middleware.RealIP.
+func ClientFromRequest(r *http.Request) domain.Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return domain.Client{UserAgent: r.UserAgent(), IP: ip}
+}
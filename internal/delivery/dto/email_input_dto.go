@@ -0,0 +1,5 @@
+package dto
+
+type EmailInputDto struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type EmailVerifyDto struct {
+	Token string `json:"token" validate:"required"`
+}
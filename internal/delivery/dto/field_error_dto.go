@@ -0,0 +1,7 @@
+package dto
+
+type FieldErrorDto struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+}
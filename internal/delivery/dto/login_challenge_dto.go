@@ -0,0 +1,8 @@
+package dto
+
+// LoginChallengeDto completes a login of a user with two-factor
+// authentication, Code is either a TOTP code or a recovery code.
+type LoginChallengeDto struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
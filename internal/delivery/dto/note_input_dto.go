@@ -1,6 +1,7 @@
 package dto
 
 type NoteInputDto struct {
-	Name    string `json:"name" validate:"required,min=1"`
-	Content string `json:"content" validate:"required,min=1"`
+	Name    string   `json:"name" validate:"required,min=1"`
+	Content string   `json:"content" validate:"required,min=1,max=100000"`
+	Tags    []string `json:"tags" validate:"max=20,dive,required,max=64"`
 }
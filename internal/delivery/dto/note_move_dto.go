@@ -0,0 +1,7 @@
+package dto
+
+import "github.com/google/uuid"
+
+type NoteMoveDto struct {
+	NotebookID *uuid.UUID `json:"notebook_id"`
+}
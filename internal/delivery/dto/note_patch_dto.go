@@ -0,0 +1,7 @@
+package dto
+
+type NotePatchDto struct {
+	Name    *string   `json:"name" validate:"required_without_all=Content Tags,omitempty,min=1"`
+	Content *string   `json:"content" validate:"required_without_all=Name Tags,omitempty,min=1,max=100000"`
+	Tags    *[]string `json:"tags" validate:"omitempty,max=20,dive,required,max=64"`
+}
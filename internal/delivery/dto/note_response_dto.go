@@ -1,9 +1,19 @@
 package dto
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 type NoteResponseDto struct {
-	ID      uuid.UUID `json:"id"`
-	Name    string    `json:"name"`
-	Content string    `json:"content"`
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Content    string     `json:"content,omitempty"`
+	Excerpt    string     `json:"excerpt,omitempty"`
+	Tags       []string   `json:"tags"`
+	NotebookID *uuid.UUID `json:"notebook_id"`
+	Version    int32      `json:"version"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,12 @@
+package dto
+
+import (
+	"time"
+)
+
+type NoteRevisionResponseDto struct {
+	Revision  int32     `json:"revision"`
+	Name      string    `json:"name"`
+	Content   string    `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
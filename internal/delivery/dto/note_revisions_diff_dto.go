@@ -0,0 +1,9 @@
+package dto
+
+type NoteRevisionsDiffDto struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	FromName string `json:"from_name"`
+	ToName   string `json:"to_name"`
+	Diff     string `json:"diff"`
+}
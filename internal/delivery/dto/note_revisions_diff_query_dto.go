@@ -0,0 +1,8 @@
+package dto
+
+// NoteRevisionsDiffQueryDto holds the revisions to compare, 0 stands for the
+// current state of the note.
+type NoteRevisionsDiffQueryDto struct {
+	From int32 `validate:"min=0"`
+	To   int32 `validate:"min=0"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type NoteSearchResultDto struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	NameHighlight string    `json:"name_highlight"`
+	Snippet       string    `json:"snippet"`
+	Rank          float32   `json:"rank"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+import "github.com/google/uuid"
+
+type NotebookInputDto struct {
+	Name     string     `json:"name" validate:"required,min=1,max=128"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
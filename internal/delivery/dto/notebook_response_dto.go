@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type NotebookResponseDto struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type NotesPageDto struct {
+	Notes      []NoteResponseDto `json:"notes"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
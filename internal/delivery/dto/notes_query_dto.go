@@ -0,0 +1,18 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type NotesQueryDto struct {
+	Limit        int32  `validate:"min=1,max=100"`
+	Cursor       string `validate:"omitempty,base64rawurl"`
+	Sort         string `validate:"oneof=created_at updated_at name"`
+	Order        string `validate:"oneof=asc desc"`
+	Excerpt      bool
+	UpdatedSince time.Time
+	NotebookID   uuid.UUID
+	Tags         []string `validate:"max=20,dive,required,max=64"`
+	TagMode      string   `validate:"oneof=all any"`
+}
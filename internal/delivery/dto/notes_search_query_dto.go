@@ -0,0 +1,7 @@
+package dto
+
+type NotesSearchQueryDto struct {
+	Query  string `validate:"required,min=1,max=256"`
+	Limit  int32  `validate:"min=1,max=100"`
+	Offset int32  `validate:"min=0"`
+}
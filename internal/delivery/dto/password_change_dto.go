@@ -0,0 +1,6 @@
+package dto
+
+type PasswordChangeDto struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type PasswordResetDto struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
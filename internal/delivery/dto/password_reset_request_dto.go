@@ -0,0 +1,5 @@
+package dto
+
+type PasswordResetRequestDto struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
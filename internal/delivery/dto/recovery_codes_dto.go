@@ -0,0 +1,5 @@
+package dto
+
+type RecoveryCodesDto struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type SessionResponseDto struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
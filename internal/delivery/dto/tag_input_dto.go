@@ -0,0 +1,5 @@
+package dto
+
+type TagInputDto struct {
+	Name string `json:"name" validate:"required,min=1,max=64"`
+}
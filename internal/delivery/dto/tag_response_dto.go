@@ -0,0 +1,9 @@
+package dto
+
+import "github.com/google/uuid"
+
+type TagResponseDto struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	NotesCount int64     `json:"notes_count"`
+}
@@ -0,0 +1,5 @@
+package dto
+
+type TwoFactorCodeDto struct {
+	Code string `json:"code" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// TwoFactorDisableDto confirms disabling two-factor authentication, Code is
+// either a TOTP code or a recovery code.
+type TwoFactorDisableDto struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -0,0 +1,6 @@
+package dto
+
+type TwoFactorSetupDto struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
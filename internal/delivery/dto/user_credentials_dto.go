@@ -5,6 +5,6 @@ import (
 )
 
 type UserCredentialsDto struct {
-	Login    string `json:"login" validate:"required,min=6"`
-	Password string `json:"password" validate:"required,min=6"`
+	Login    string `json:"login" validate:"required"`
+	Password string `json:"password" validate:"required"`
 }
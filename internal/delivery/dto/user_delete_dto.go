@@ -0,0 +1,5 @@
+package dto
+
+type UserDeleteDto struct {
+	Password string `json:"password" validate:"required"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+)
+
+// UserProfileDto describes the user. Email is omitted when the user hasn't
+// set one, EmailVerified tells whether password reset mail can be sent to it.
+type UserProfileDto struct {
+	ID               uuid.UUID `json:"id"`
+	Login            string    `json:"login"`
+	Email            string    `json:"email,omitempty"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+}
@@ -4,7 +4,13 @@ import (
 	"github.com/google/uuid"
 )
 
+// UserResponseDto is the result of a login. When the user has two-factor
+// authentication enabled, the login isn't complete yet: TwoFactorRequired is
+// set and ChallengeToken has to be sent with a code to POST
+// /users/login/2fa, there's no access token.
 type UserResponseDto struct {
-	ID          uuid.UUID `json:"id"`
-	AccessToken string    `json:"access_token"`
+	ID                uuid.UUID `json:"id"`
+	AccessToken       string    `json:"access_token,omitempty"`
+	TwoFactorRequired bool      `json:"two_factor_required,omitempty"`
+	ChallengeToken    string    `json:"challenge_token,omitempty"`
 }
@@ -0,0 +1,59 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const anyETag = "*"
+
+func FormatETag(version int32) string {
+	return strconv.Quote(strconv.Itoa(int(version)))
+}
+
+func SetETag(w http.ResponseWriter, version int32) {
+	w.Header().Set("ETag", FormatETag(version))
+}
+
+// ParseIfMatch returns the versions listed in an If-Match header, see RFC 9110
+// section 13.1.1. "*" is returned as version 0, which matches any version.
+// Weak and unknown ETags never match strongly and are skipped, false is
+// returned when no version is left.
+func ParseIfMatch(header string) ([]int32, bool) {
+	if strings.TrimSpace(header) == anyETag {
+		return []int32{0}, true
+	}
+
+	var versions []int32
+	for _, candidate := range strings.Split(header, ",") {
+		value, err := strconv.Unquote(strings.TrimSpace(candidate))
+		if err != nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || version < 1 {
+			continue
+		}
+
+		versions = append(versions, int32(version))
+	}
+
+	return versions, len(versions) != 0
+}
+
+// MatchIfNoneMatch reports whether an If-None-Match header matches the version
+// using the weak comparison, as GET requests do.
+func MatchIfNoneMatch(header string, version int32) bool {
+	etag := FormatETag(version)
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == anyETag || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
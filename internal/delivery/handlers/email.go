@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/delivery/middleware"
+)
+
+func (h UsersHandler) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	user, err := h.usersService.GetUser(r.Context(), principal.UserID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, user)
+}
+
+func (h UsersHandler) setEmailHandler(w http.ResponseWriter, r *http.Request, emailInput dto.EmailInputDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	if err := h.usersService.SetEmail(r.Context(), principal.UserID, emailInput); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h UsersHandler) verifyEmailHandler(w http.ResponseWriter, r *http.Request, emailVerify dto.EmailVerifyDto) {
+	if err := h.usersService.VerifyEmail(r.Context(), emailVerify); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
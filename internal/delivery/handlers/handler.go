@@ -3,23 +3,81 @@ package handlers
 import (
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
+	"net/http"
+	"notes-service-go/internal/delivery/middleware"
 	"notes-service-go/internal/service"
+	"notes-service-go/pkg/auth"
+	"notes-service-go/pkg/ratelimit"
 	"time"
 )
 
+// Timeouts are the request deadlines of the routes. Search applies to the
+// notes search, NoteWrite to creating and updating notes, which waits for
+// Yandex Speller, and Request to all other routes.
+type Timeouts struct {
+	Request   time.Duration
+	Search    time.Duration
+	NoteWrite time.Duration
+}
+
+// RateLimits are the request limits of the routes, they're grouped like
+// Timeouts. Auth applies to the users routes that don't need an access token,
+// such as registration and login, Search and NoteWrite to the same routes as
+// in Timeouts, and Default to all other routes.
+type RateLimits struct {
+	Limiter   *ratelimit.Limiter
+	Default   ratelimit.Limit
+	Auth      ratelimit.Limit
+	Search    ratelimit.Limit
+	NoteWrite ratelimit.Limit
+}
+
+func (l RateLimits) defaultPolicy() func(http.Handler) http.Handler {
+	return middleware.RateLimit(l.Limiter, "default", l.Default)
+}
+
+func (l RateLimits) authPolicy() func(http.Handler) http.Handler {
+	return middleware.RateLimit(l.Limiter, "auth", l.Auth)
+}
+
+func (l RateLimits) searchPolicy() func(http.Handler) http.Handler {
+	return middleware.RateLimit(l.Limiter, "search", l.Search)
+}
+
+func (l RateLimits) noteWritePolicy() func(http.Handler) http.Handler {
+	return middleware.RateLimit(l.Limiter, "note_write", l.NoteWrite)
+}
+
 type Handler struct {
-	UsersHandler *UsersHandler
-	NotesHandler *NotesHandler
+	UsersHandler     *UsersHandler
+	NotesHandler     *NotesHandler
+	TagsHandler      *TagsHandler
+	NotebooksHandler *NotebooksHandler
+
+	tokenManager auth.TokenManager
+	timeouts     Timeouts
+	rateLimits   RateLimits
 }
 
-func NewHandler(services *service.Services, validator *validator.Validate, refreshTokenTTL time.Duration) *Handler {
+func NewHandler(services *service.Services, tokenManager auth.TokenManager, validator *validator.Validate, refreshTokenTTL time.Duration, timeouts Timeouts, rateLimits RateLimits) *Handler {
 	return &Handler{
-		UsersHandler: NewUsersHandler(services.Users, validator, refreshTokenTTL),
-		NotesHandler: NewNoteHandler(services.Notes, validator),
+		UsersHandler:     NewUsersHandler(services.Users, tokenManager, validator, refreshTokenTTL, timeouts, rateLimits),
+		NotesHandler:     NewNoteHandler(services.Notes, validator, timeouts, rateLimits),
+		TagsHandler:      NewTagsHandler(services.Tags, validator, timeouts, rateLimits),
+		NotebooksHandler: NewNotebooksHandler(services.Notebooks, validator, timeouts, rateLimits),
+		tokenManager:     tokenManager,
+		timeouts:         timeouts,
+		rateLimits:       rateLimits,
 	}
 }
 
 func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.With(h.rateLimits.defaultPolicy()).Get("/.well-known/jwks.json", h.jwksHandler)
 	r.Mount("/users", h.UsersHandler.usersHandlers())
-	r.Mount("/notes", h.NotesHandler.notesHandlers())
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Authenticate(h.tokenManager, h.timeouts.Request))
+		r.Mount("/notes", h.NotesHandler.notesHandlers())
+		r.Mount("/tags", h.TagsHandler.tagsHandlers())
+		r.Mount("/notebooks", h.NotebooksHandler.notebooksHandlers())
+	})
 }
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+	"notes-service-go/internal/delivery"
+)
+
+// jwksCacheControl lets clients cache the key set for 5 minutes. A new signing
+// key has to be published at least that long before it starts signing tokens.
+const jwksCacheControl = "public, max-age=300"
+
+func (h *Handler) jwksHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", jwksCacheControl)
+	delivery.RespondWithJSON(w, http.StatusOK, h.tokenManager.JWKS())
+}
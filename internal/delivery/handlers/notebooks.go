@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/delivery/middleware"
+	"notes-service-go/internal/domain"
+	"notes-service-go/internal/service"
+)
+
+type NotebooksHandler struct {
+	notebooksService service.Notebooks
+	validator        *validator.Validate
+	timeouts         Timeouts
+	rateLimits       RateLimits
+}
+
+func NewNotebooksHandler(notebooksService service.Notebooks, validator *validator.Validate, timeouts Timeouts, rateLimits RateLimits) *NotebooksHandler {
+	return &NotebooksHandler{
+		notebooksService: notebooksService,
+		validator:        validator,
+		timeouts:         timeouts,
+		rateLimits:       rateLimits,
+	}
+}
+
+func (h NotebooksHandler) notebooksHandlers() http.Handler {
+	rg := chi.NewRouter()
+	rg.Group(func(r chi.Router) {
+		r.Use(middleware.Deadline(h.timeouts.Request), h.rateLimits.defaultPolicy())
+		r.Get("/", h.getHandler)
+		r.Post("/", middleware.CheckNotebookInput(h.validator, h.createHandler))
+		r.Get("/{id}", h.getByIDHandler)
+		r.Put("/{id}", middleware.CheckNotebookInput(h.validator, h.updateHandler))
+		r.Delete("/{id}", h.deleteHandler)
+	})
+
+	return rg
+}
+
+func (h NotebooksHandler) getHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	notebooks, err := h.notebooksService.GetNotebooks(r.Context(), principal.UserID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, notebooks)
+}
+
+func (h NotebooksHandler) getByIDHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNotebookID.Wrap(err))
+		return
+	}
+
+	notebook, err := h.notebooksService.GetNotebook(r.Context(), principal.UserID, notebookID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, notebook)
+}
+
+func (h NotebooksHandler) createHandler(w http.ResponseWriter, r *http.Request, notebookInput dto.NotebookInputDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	notebook, err := h.notebooksService.CreateNotebook(r.Context(), principal.UserID, notebookInput)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusCreated, notebook)
+}
+
+func (h NotebooksHandler) updateHandler(w http.ResponseWriter, r *http.Request, notebookInput dto.NotebookInputDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNotebookID.Wrap(err))
+		return
+	}
+
+	notebook, err := h.notebooksService.UpdateNotebook(r.Context(), principal.UserID, notebookID, notebookInput)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, notebook)
+}
+
+func (h NotebooksHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	notebookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNotebookID.Wrap(err))
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = service.NotebookDeleteMoveToRoot
+	}
+	if mode != service.NotebookDeleteCascade && mode != service.NotebookDeleteMoveToRoot {
+		delivery.RespondWithError(w, domain.ErrInvalidNotebookDelete)
+		return
+	}
+
+	if err = h.notebooksService.DeleteNotebook(r.Context(), principal.UserID, notebookID, mode); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
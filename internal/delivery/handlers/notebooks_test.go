@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"notes-service-go/internal/service"
+	"notes-service-go/pkg/ratelimit"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNotebooks implements service.Notebooks with the methods the tests need,
+// calling any other method panics.
+type fakeNotebooks struct {
+	service.Notebooks
+
+	update func(notebookID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error)
+}
+
+func (f *fakeNotebooks) UpdateNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error) {
+	return f.update(notebookID, notebookInput)
+}
+
+func newTestNotebooksHandler(notebooksService service.Notebooks) http.Handler {
+	rateLimits := newTestRateLimits(ratelimit.Limit{Requests: 100, Period: time.Minute})
+	return NewNotebooksHandler(notebooksService, validator.New(), testTimeouts, rateLimits).notebooksHandlers()
+}
+
+func TestUpdateNotebookHandler(t *testing.T) {
+	notebookID, parentID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "moved",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "moved into a descendant",
+			err:        domain.ErrNotebookCycle,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "notebook_cycle",
+		},
+		{
+			name:       "missing parent",
+			err:        domain.ErrParentNotebookNotFound,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "parent_notebook_not_found",
+		},
+		{
+			name:       "missing notebook",
+			err:        domain.ErrNotebookNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "notebook_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notebooks := &fakeNotebooks{
+				update: func(gotNotebookID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error) {
+					if gotNotebookID != notebookID || notebookInput.ParentID == nil || *notebookInput.ParentID != parentID {
+						t.Errorf("UpdateNotebook got notebook %s input %+v, want notebook %s parent %s", gotNotebookID, notebookInput, notebookID, parentID)
+					}
+					if tt.err != nil {
+						return dto.NotebookResponseDto{}, tt.err
+					}
+					return dto.NotebookResponseDto{ID: notebookID, Name: notebookInput.Name, ParentID: notebookInput.ParentID}, nil
+				},
+			}
+
+			body := `{"name":"child","parent_id":"` + parentID.String() + `"}`
+			req := httptest.NewRequest(http.MethodPut, "/"+notebookID.String(), strings.NewReader(body))
+			rec := httptest.NewRecorder()
+			newTestNotebooksHandler(notebooks).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", rec.Body, tt.wantCode)
+			}
+		})
+	}
+}
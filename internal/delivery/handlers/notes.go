@@ -3,72 +3,231 @@ package handlers
 import (
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
-	"log"
+	"github.com/google/uuid"
 	"net/http"
 	"notes-service-go/internal/delivery"
 	"notes-service-go/internal/delivery/dto"
 	"notes-service-go/internal/delivery/middleware"
 	"notes-service-go/internal/domain"
 	"notes-service-go/internal/service"
-	"strings"
 )
 
 type NotesHandler struct {
 	notesService service.Notes
 	validator    *validator.Validate
+	timeouts     Timeouts
+	rateLimits   RateLimits
 }
 
-func NewNoteHandler(notesService service.Notes, validator *validator.Validate) *NotesHandler {
+func NewNoteHandler(notesService service.Notes, validator *validator.Validate, timeouts Timeouts, rateLimits RateLimits) *NotesHandler {
 	return &NotesHandler{
 		notesService: notesService,
 		validator:    validator,
+		timeouts:     timeouts,
+		rateLimits:   rateLimits,
 	}
 }
 
 func (h NotesHandler) notesHandlers() http.Handler {
 	rg := chi.NewRouter()
 	rg.Group(func(r chi.Router) {
-		r.Get("/", h.getHandler)
+		r.Use(middleware.Deadline(h.timeouts.Request), h.rateLimits.defaultPolicy())
+		r.Get("/", middleware.CheckNotesQuery(h.validator, h.getHandler))
+		r.Get("/trash", h.getTrashHandler)
+		r.Delete("/trash", h.emptyTrashHandler)
+		r.Get("/{id}", h.getByIDHandler)
+		r.Put("/{id}/notebook", middleware.CheckNoteMoveInput(h.moveHandler))
+		r.Get("/{id}/revisions", h.getRevisionsHandler)
+		r.Get("/{id}/revisions/diff", middleware.CheckNoteRevisionsDiffQuery(h.validator, h.diffRevisionsHandler))
+		r.Get("/{id}/revisions/{rev}", h.getRevisionHandler)
+		r.Post("/{id}/revisions/{rev}/restore", h.restoreRevisionHandler)
+		r.Delete("/{id}", h.deleteHandler)
+		r.Post("/{id}/restore", h.restoreHandler)
+	})
+	rg.Group(func(r chi.Router) {
+		r.Use(middleware.Deadline(h.timeouts.Search), h.rateLimits.searchPolicy())
+		r.Get("/search", middleware.CheckNotesSearchQuery(h.validator, h.searchHandler))
+	})
+	rg.Group(func(r chi.Router) {
+		r.Use(middleware.Deadline(h.timeouts.NoteWrite), h.rateLimits.noteWritePolicy())
 		r.Post("/", middleware.CheckNoteInput(h.validator, h.createHandler))
+		r.Put("/{id}", middleware.CheckNoteInput(h.validator, h.updateHandler))
+		r.Patch("/{id}", middleware.CheckNotePatchInput(h.validator, h.patchHandler))
 	})
 
 	return rg
 }
 
-func (h NotesHandler) getHandler(w http.ResponseWriter, r *http.Request) {
-	accessToken := r.Header.Get("Authorization")
+func (h NotesHandler) getHandler(w http.ResponseWriter, r *http.Request, notesQuery dto.NotesQueryDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
 
-	notes, err := h.notesService.GetNotes(accessToken)
+	notes, err := h.notesService.GetNotes(r.Context(), principal.UserID, notesQuery)
 	if err != nil {
-		log.Println(err)
-		if strings.HasPrefix(err.Error(), domain.ErrInvalidAccessToken) || strings.HasPrefix(err.Error(), domain.ErrAccessTokenUndefined) {
-			delivery.RespondWithError(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-		delivery.RespondWithError(w, http.StatusInternalServerError, domain.ErrGettingNotes)
+		delivery.RespondWithError(w, err)
 		return
 	}
 
 	delivery.RespondWithJSON(w, http.StatusOK, notes)
 }
 
+func (h NotesHandler) searchHandler(w http.ResponseWriter, r *http.Request, searchQuery dto.NotesSearchQueryDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	results, err := h.notesService.SearchNotes(r.Context(), principal.UserID, searchQuery)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, results)
+}
+
+func (h NotesHandler) getByIDHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	note, err := h.notesService.GetNote(r.Context(), principal.UserID, noteID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.SetETag(w, note.Version)
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && delivery.MatchIfNoneMatch(ifNoneMatch, note.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, note)
+}
+
 func (h NotesHandler) createHandler(w http.ResponseWriter, r *http.Request, noteInput dto.NoteInputDto) {
-	accessToken := r.Header.Get("Authorization")
+	principal := middleware.PrincipalFromContext(r.Context())
 
-	note, err := h.notesService.CreateNote(noteInput, accessToken)
+	note, err := h.notesService.CreateNote(r.Context(), principal.UserID, noteInput)
 	if err != nil {
-		log.Println(err)
-		if strings.HasPrefix(err.Error(), domain.ErrInvalidAccessToken) || strings.HasPrefix(err.Error(), domain.ErrAccessTokenUndefined) {
-			delivery.RespondWithError(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-		if strings.HasPrefix(err.Error(), domain.ErrSpellingText) {
-			delivery.RespondWithError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-		delivery.RespondWithError(w, http.StatusInternalServerError, domain.ErrCreatingNote)
+		delivery.RespondWithError(w, err)
 		return
 	}
 
+	delivery.SetETag(w, note.Version)
 	delivery.RespondWithJSON(w, http.StatusCreated, note)
 }
+
+func (h NotesHandler) updateHandler(w http.ResponseWriter, r *http.Request, noteInput dto.NoteInputDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	versions, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.notesService.UpdateNote(r.Context(), principal.UserID, noteID, versions, noteInput)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.SetETag(w, note.Version)
+	delivery.RespondWithJSON(w, http.StatusOK, note)
+}
+
+func (h NotesHandler) patchHandler(w http.ResponseWriter, r *http.Request, notePatch dto.NotePatchDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	versions, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.notesService.PatchNote(r.Context(), principal.UserID, noteID, versions, notePatch)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.SetETag(w, note.Version)
+	delivery.RespondWithJSON(w, http.StatusOK, note)
+}
+
+func (h NotesHandler) moveHandler(w http.ResponseWriter, r *http.Request, noteMove dto.NoteMoveDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	versions, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.notesService.MoveNote(r.Context(), principal.UserID, noteID, versions, noteMove)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.SetETag(w, note.Version)
+	delivery.RespondWithJSON(w, http.StatusOK, note)
+}
+
+func (h NotesHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	versions, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err = h.notesService.DeleteNote(r.Context(), principal.UserID, noteID, versions); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseIfMatch reads the expected note versions from the If-Match header and
+// responds with an error when it's missing or malformed.
+func parseIfMatch(w http.ResponseWriter, r *http.Request) ([]int32, bool) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		delivery.RespondWithError(w, domain.ErrNoteVersionRequired)
+		return nil, false
+	}
+
+	versions, ok := delivery.ParseIfMatch(ifMatch)
+	if !ok {
+		delivery.RespondWithError(w, domain.ErrNoteVersionMismatch)
+		return nil, false
+	}
+
+	return versions, true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"notes-service-go/internal/service"
+	"notes-service-go/pkg/ratelimit"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testTimeouts = Timeouts{Request: time.Second, Search: time.Second, NoteWrite: time.Second}
+
+// fakeNotes implements service.Notes with the methods the tests need, calling
+// any other method panics.
+type fakeNotes struct {
+	service.Notes
+
+	update     func(noteID uuid.UUID, versions []int32, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error)
+	restore    func(noteID uuid.UUID) (dto.NoteResponseDto, error)
+	emptyTrash func() error
+}
+
+func (f *fakeNotes) UpdateNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error) {
+	return f.update(noteID, versions, noteInput)
+}
+
+func (f *fakeNotes) RestoreNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) (dto.NoteResponseDto, error) {
+	return f.restore(noteID)
+}
+
+func (f *fakeNotes) EmptyTrash(ctx context.Context, userID uuid.UUID) error {
+	return f.emptyTrash()
+}
+
+func newTestNotesHandler(notesService service.Notes) http.Handler {
+	rateLimits := newTestRateLimits(ratelimit.Limit{Requests: 100, Period: time.Minute})
+	return NewNoteHandler(notesService, validator.New(), testTimeouts, rateLimits).notesHandlers()
+}
+
+func TestUpdateNoteHandlerIfMatch(t *testing.T) {
+	noteID := uuid.New()
+
+	tests := []struct {
+		name         string
+		ifMatch      string
+		err          error
+		wantVersions []int32
+		wantStatus   int
+		wantCode     string
+		wantETag     string
+	}{
+		{
+			name:         "matching version",
+			ifMatch:      `"3"`,
+			wantVersions: []int32{3},
+			wantStatus:   http.StatusOK,
+			wantETag:     `"4"`,
+		},
+		{
+			name:         "any version",
+			ifMatch:      "*",
+			wantVersions: []int32{0},
+			wantStatus:   http.StatusOK,
+			wantETag:     `"4"`,
+		},
+		{
+			name:         "stale version",
+			ifMatch:      `"2", "3"`,
+			err:          domain.ErrNoteVersionMismatch,
+			wantVersions: []int32{2, 3},
+			wantStatus:   http.StatusPreconditionFailed,
+			wantCode:     "note_version_mismatch",
+		},
+		{
+			name:       "weak etag never matches",
+			ifMatch:    `W/"3"`,
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "note_version_mismatch",
+		},
+		{
+			name:       "no if-match",
+			wantStatus: http.StatusPreconditionRequired,
+			wantCode:   "note_version_required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			notes := &fakeNotes{
+				update: func(gotNoteID uuid.UUID, versions []int32, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error) {
+					called = true
+					if gotNoteID != noteID || !reflect.DeepEqual(versions, tt.wantVersions) {
+						t.Errorf("UpdateNote got note %s versions %v, want note %s versions %v", gotNoteID, versions, noteID, tt.wantVersions)
+					}
+					if tt.err != nil {
+						return dto.NoteResponseDto{}, tt.err
+					}
+					return dto.NoteResponseDto{ID: noteID, Name: noteInput.Name, Content: noteInput.Content, Version: 4}, nil
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/"+noteID.String(), strings.NewReader(`{"name":"note","content":"text"}`))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			newTestNotesHandler(notes).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", rec.Body, tt.wantCode)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if called != (tt.wantVersions != nil) {
+				t.Errorf("UpdateNote called = %t, want %t", called, tt.wantVersions != nil)
+			}
+		})
+	}
+}
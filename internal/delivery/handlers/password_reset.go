@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/dto"
+)
+
+// passwordResetRequestHandler always responds with 202 Accepted, whether a
+// user with the email exists or not.
+func (h UsersHandler) passwordResetRequestHandler(w http.ResponseWriter, r *http.Request, passwordResetRequest dto.PasswordResetRequestDto) {
+	if err := h.usersService.RequestPasswordReset(r.Context(), passwordResetRequest); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h UsersHandler) passwordResetHandler(w http.ResponseWriter, r *http.Request, passwordReset dto.PasswordResetDto) {
+	if err := h.usersService.ResetPassword(r.Context(), passwordReset, delivery.ClientFromRequest(r)); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
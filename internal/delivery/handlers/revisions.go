@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/delivery/middleware"
+	"notes-service-go/internal/domain"
+	"strconv"
+)
+
+func (h NotesHandler) getRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	revisions, err := h.notesService.GetNoteRevisions(r.Context(), principal.UserID, noteID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, revisions)
+}
+
+func (h NotesHandler) getRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, revision, ok := parseRevisionParams(w, r)
+	if !ok {
+		return
+	}
+
+	noteRevision, err := h.notesService.GetNoteRevision(r.Context(), principal.UserID, noteID, revision)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, noteRevision)
+}
+
+func (h NotesHandler) diffRevisionsHandler(w http.ResponseWriter, r *http.Request, diffQuery dto.NoteRevisionsDiffQueryDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	revisionsDiff, err := h.notesService.DiffNoteRevisions(r.Context(), principal.UserID, noteID, diffQuery)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, revisionsDiff)
+}
+
+func (h NotesHandler) restoreRevisionHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, revision, ok := parseRevisionParams(w, r)
+	if !ok {
+		return
+	}
+
+	versions, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	note, err := h.notesService.RestoreNoteRevision(r.Context(), principal.UserID, noteID, revision, versions)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.SetETag(w, note.Version)
+	delivery.RespondWithJSON(w, http.StatusOK, note)
+}
+
+// parseRevisionParams parses the note id and revision url params and responds
+// with an error when one of them is invalid.
+func parseRevisionParams(w http.ResponseWriter, r *http.Request) (uuid.UUID, int32, bool) {
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return uuid.Nil, 0, false
+	}
+
+	revision, err := strconv.ParseInt(chi.URLParam(r, "rev"), 10, 32)
+	if err != nil || revision < 1 {
+		delivery.RespondWithError(w, domain.ErrInvalidRevision.Wrap(err))
+		return uuid.Nil, 0, false
+	}
+
+	return noteID, int32(revision), true
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/middleware"
+	"notes-service-go/internal/domain"
+)
+
+func (h UsersHandler) getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	sessions, err := h.usersService.GetSessions(r.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, sessions)
+}
+
+func (h UsersHandler) deleteSessionHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidSessionID.Wrap(err))
+		return
+	}
+
+	if err = h.usersService.DeleteSession(r.Context(), principal.UserID, sessionID); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
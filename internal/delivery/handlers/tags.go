@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/delivery/middleware"
+	"notes-service-go/internal/domain"
+	"notes-service-go/internal/service"
+)
+
+type TagsHandler struct {
+	tagsService service.Tags
+	validator   *validator.Validate
+	timeouts    Timeouts
+	rateLimits  RateLimits
+}
+
+func NewTagsHandler(tagsService service.Tags, validator *validator.Validate, timeouts Timeouts, rateLimits RateLimits) *TagsHandler {
+	return &TagsHandler{
+		tagsService: tagsService,
+		validator:   validator,
+		timeouts:    timeouts,
+		rateLimits:  rateLimits,
+	}
+}
+
+func (h TagsHandler) tagsHandlers() http.Handler {
+	rg := chi.NewRouter()
+	rg.Group(func(r chi.Router) {
+		r.Use(middleware.Deadline(h.timeouts.Request), h.rateLimits.defaultPolicy())
+		r.Get("/", h.getHandler)
+		r.Patch("/{id}", middleware.CheckTagInput(h.validator, h.updateHandler))
+		r.Delete("/{id}", h.deleteHandler)
+	})
+
+	return rg
+}
+
+func (h TagsHandler) getHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	tags, err := h.tagsService.GetTags(r.Context(), principal.UserID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, tags)
+}
+
+func (h TagsHandler) updateHandler(w http.ResponseWriter, r *http.Request, tagInput dto.TagInputDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	tagID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidTagID.Wrap(err))
+		return
+	}
+
+	tag, err := h.tagsService.UpdateTag(r.Context(), principal.UserID, tagID, tagInput)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, tag)
+}
+
+func (h TagsHandler) deleteHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	tagID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidTagID.Wrap(err))
+		return
+	}
+
+	if err = h.tagsService.DeleteTag(r.Context(), principal.UserID, tagID); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/middleware"
+	"notes-service-go/internal/domain"
+)
+
+func (h NotesHandler) getTrashHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	notes, err := h.notesService.GetTrash(r.Context(), principal.UserID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, notes)
+}
+
+func (h NotesHandler) restoreHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	noteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		delivery.RespondWithError(w, domain.ErrInvalidNoteID.Wrap(err))
+		return
+	}
+
+	note, err := h.notesService.RestoreNote(r.Context(), principal.UserID, noteID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.SetETag(w, note.Version)
+	delivery.RespondWithJSON(w, http.StatusOK, note)
+}
+
+func (h NotesHandler) emptyTrashHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	if err := h.notesService.EmptyTrash(r.Context(), principal.UserID); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
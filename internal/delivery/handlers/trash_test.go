@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"strings"
+	"testing"
+)
+
+func TestRestoreNoteHandler(t *testing.T) {
+	noteID := uuid.New()
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantETag   string
+	}{
+		{
+			name:       "restored",
+			wantStatus: http.StatusOK,
+			wantETag:   `"5"`,
+		},
+		{
+			name:       "not in trash",
+			err:        domain.ErrDeletedNoteNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "deleted_note_not_found",
+		},
+		{
+			name:       "failed",
+			err:        domain.ErrRestoringNote.Wrap(errors.New("connection refused")),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := &fakeNotes{
+				restore: func(gotNoteID uuid.UUID) (dto.NoteResponseDto, error) {
+					if gotNoteID != noteID {
+						t.Errorf("RestoreNote got note %s, want %s", gotNoteID, noteID)
+					}
+					if tt.err != nil {
+						return dto.NoteResponseDto{}, tt.err
+					}
+					return dto.NoteResponseDto{ID: noteID, Version: 5}, nil
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/"+noteID.String()+"/restore", nil)
+			rec := httptest.NewRecorder()
+			newTestNotesHandler(notes).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", rec.Body, tt.wantCode)
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
+
+func TestEmptyTrashHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "emptied",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "failed",
+			err:        domain.ErrEmptyingTrash.Wrap(errors.New("connection refused")),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			notes := &fakeNotes{
+				emptyTrash: func() error {
+					calls++
+					return tt.err
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/trash", nil)
+			rec := httptest.NewRecorder()
+			newTestNotesHandler(notes).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", rec.Body, tt.wantCode)
+			}
+			if calls != 1 {
+				t.Errorf("EmptyTrash called %d times, want 1", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/delivery/middleware"
+)
+
+func (h UsersHandler) loginTwoFactorHandler(w http.ResponseWriter, r *http.Request, loginChallenge dto.LoginChallengeDto) {
+	user, refreshToken, err := h.usersService.CompleteLogin(r.Context(), loginChallenge, delivery.ClientFromRequest(r))
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+	delivery.SetCookie(w, refreshToken, h.refreshTokenTTL)
+	delivery.RespondWithJSON(w, http.StatusOK, user)
+}
+
+func (h UsersHandler) setupTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	setup, err := h.usersService.SetupTwoFactor(r.Context(), principal.UserID)
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, setup)
+}
+
+func (h UsersHandler) verifyTwoFactorHandler(w http.ResponseWriter, r *http.Request, twoFactorCode dto.TwoFactorCodeDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	recoveryCodes, err := h.usersService.EnableTwoFactor(r.Context(), principal.UserID, principal.SessionID, twoFactorCode, delivery.ClientFromRequest(r))
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.RespondWithJSON(w, http.StatusOK, recoveryCodes)
+}
+
+func (h UsersHandler) disableTwoFactorHandler(w http.ResponseWriter, r *http.Request, twoFactorDisable dto.TwoFactorDisableDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	err := h.usersService.DisableTwoFactor(r.Context(), principal.UserID, principal.SessionID, twoFactorDisable, delivery.ClientFromRequest(r))
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,55 +1,79 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/go-chi/chi"
 	"github.com/go-playground/validator/v10"
-	"log"
 	"net/http"
 	"notes-service-go/internal/delivery"
 	"notes-service-go/internal/delivery/dto"
 	"notes-service-go/internal/delivery/middleware"
 	"notes-service-go/internal/domain"
 	"notes-service-go/internal/service"
-	"strings"
+	"notes-service-go/pkg/auth"
 	"time"
 )
 
 type UsersHandler struct {
 	usersService service.Users
+	tokenManager auth.TokenManager
 	validator    *validator.Validate
 
 	refreshTokenTTL time.Duration
+	timeouts        Timeouts
+	rateLimits      RateLimits
 }
 
-func NewUsersHandler(usersService service.Users, validator *validator.Validate, refreshTokenTTL time.Duration) *UsersHandler {
+func NewUsersHandler(usersService service.Users, tokenManager auth.TokenManager, validator *validator.Validate, refreshTokenTTL time.Duration, timeouts Timeouts, rateLimits RateLimits) *UsersHandler {
 	return &UsersHandler{
 		usersService:    usersService,
+		tokenManager:    tokenManager,
 		validator:       validator,
 		refreshTokenTTL: refreshTokenTTL,
+		timeouts:        timeouts,
+		rateLimits:      rateLimits,
 	}
 }
 
 func (h UsersHandler) usersHandlers() http.Handler {
 	rg := chi.NewRouter()
 	rg.Group(func(r chi.Router) {
-		r.Post("/register", middleware.CheckUserCredentialsInput(h.validator, h.registerHandler))
-		r.Get("/refresh", h.refreshHandler)
-		r.Post("/login", middleware.CheckUserCredentialsInput(h.validator, h.loginHandler))
-		r.Get("/logout", h.logoutHandler)
+		r.Use(middleware.Deadline(h.timeouts.Request))
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.rateLimits.authPolicy())
+			r.Post("/register", middleware.CheckUserCredentialsInput(h.validator, h.registerHandler))
+			r.Get("/refresh", h.refreshHandler)
+			r.Post("/login", middleware.CheckUserCredentialsInput(h.validator, h.loginHandler))
+			r.Post("/login/2fa", middleware.CheckLoginChallengeInput(h.validator, h.loginTwoFactorHandler))
+			r.Post("/password/reset-request", middleware.CheckPasswordResetRequestInput(h.validator, h.passwordResetRequestHandler))
+			r.Post("/password/reset", middleware.CheckPasswordResetInput(h.validator, h.passwordResetHandler))
+			r.Post("/email/verify", middleware.CheckEmailVerifyInput(h.validator, h.verifyEmailHandler))
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Authenticate(h.tokenManager, h.timeouts.Request), h.rateLimits.defaultPolicy())
+			r.Get("/logout", h.logoutHandler)
+			r.Get("/logout/all", h.logoutAllHandler)
+			r.Get("/sessions", h.getSessionsHandler)
+			r.Delete("/sessions/{id}", h.deleteSessionHandler)
+			r.Post("/password", middleware.CheckPasswordChangeInput(h.validator, h.changePasswordHandler))
+			r.Get("/me", h.getUserHandler)
+			r.Delete("/me", middleware.CheckUserDeleteInput(h.validator, h.deleteUserHandler))
+			r.Put("/email", middleware.CheckEmailInput(h.validator, h.setEmailHandler))
+			r.Post("/2fa/setup", h.setupTwoFactorHandler)
+			r.Post("/2fa/verify", middleware.CheckTwoFactorCodeInput(h.validator, h.verifyTwoFactorHandler))
+			r.Delete("/2fa", middleware.CheckTwoFactorDisableInput(h.validator, h.disableTwoFactorHandler))
+		})
 	})
 
 	return rg
 }
 
 func (h UsersHandler) registerHandler(w http.ResponseWriter, r *http.Request, userCredentials dto.UserCredentialsDto) {
-	user, refreshToken, err := h.usersService.CreateUser(userCredentials)
+	user, refreshToken, err := h.usersService.CreateUser(r.Context(), userCredentials, delivery.ClientFromRequest(r))
 	if err != nil {
-		log.Println(err)
-		if strings.HasPrefix(err.Error(), domain.ErrUserAlreadyExists) {
-			delivery.RespondWithError(w, http.StatusBadRequest, domain.ErrUserAlreadyExists)
-			return
-		}
-		delivery.RespondWithError(w, http.StatusInternalServerError, domain.ErrCreatingUser)
+		delivery.RespondWithError(w, err)
 		return
 	}
 	delivery.SetCookie(w, refreshToken, h.refreshTokenTTL)
@@ -59,24 +83,21 @@ func (h UsersHandler) registerHandler(w http.ResponseWriter, r *http.Request, us
 func (h UsersHandler) refreshHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("refresh_token")
 	if err != nil {
-		log.Println(err)
-		if err == http.ErrNoCookie {
-			delivery.RespondWithError(w, http.StatusUnauthorized, domain.ErrRefreshTokenUndefined)
+		if errors.Is(err, http.ErrNoCookie) {
+			delivery.RespondWithError(w, domain.ErrRefreshTokenUndefined)
 			return
 		}
-		delivery.RespondWithError(w, http.StatusUnauthorized, domain.ErrGettingRefreshTokenFromCookie)
+		delivery.RespondWithError(w, domain.ErrGettingRefreshTokenFromCookie.Wrap(err))
 		return
 	}
 	refreshToken := cookie.Value
 
-	user, refreshToken, err := h.usersService.Refresh(refreshToken)
+	user, refreshToken, err := h.usersService.Refresh(r.Context(), refreshToken, delivery.ClientFromRequest(r))
 	if err != nil {
-		log.Println(err)
-		if strings.HasPrefix(err.Error(), domain.ErrInvalidRefreshToken) {
-			delivery.RespondWithError(w, http.StatusUnauthorized, domain.ErrInvalidRefreshToken)
-			return
+		if errors.Is(err, domain.ErrRefreshTokenReused) {
+			delivery.DeleteCookie(w)
 		}
-		delivery.RespondWithError(w, http.StatusInternalServerError, domain.ErrRefresh)
+		delivery.RespondWithError(w, err)
 		return
 	}
 	delivery.SetCookie(w, refreshToken, h.refreshTokenTTL)
@@ -84,32 +105,61 @@ func (h UsersHandler) refreshHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h UsersHandler) loginHandler(w http.ResponseWriter, r *http.Request, userCredentials dto.UserCredentialsDto) {
-	user, refreshToken, err := h.usersService.Login(userCredentials)
+	user, refreshToken, err := h.usersService.Login(r.Context(), userCredentials, delivery.ClientFromRequest(r))
 	if err != nil {
-		log.Println(err)
-		if strings.HasPrefix(err.Error(), domain.ErrUserNotFound) || strings.HasPrefix(err.Error(), domain.ErrWrongPassword) {
-			delivery.RespondWithError(w, http.StatusBadRequest, domain.ErrWrongCredentials)
-			return
-		}
-		delivery.RespondWithError(w, http.StatusInternalServerError, domain.ErrLogin)
+		delivery.RespondWithError(w, err)
 		return
 	}
-	delivery.SetCookie(w, refreshToken, h.refreshTokenTTL)
+	// There's no refresh token until a user with two-factor authentication
+	// completes the login challenge.
+	if refreshToken != "" {
+		delivery.SetCookie(w, refreshToken, h.refreshTokenTTL)
+	}
 	delivery.RespondWithJSON(w, http.StatusOK, user)
 }
 
 func (h UsersHandler) logoutHandler(w http.ResponseWriter, r *http.Request) {
-	accessToken := r.Header.Get("Authorization")
+	principal := middleware.PrincipalFromContext(r.Context())
 
-	if err := h.usersService.Logout(accessToken); err != nil {
-		log.Println(err)
-		if strings.HasPrefix(err.Error(), domain.ErrInvalidAccessToken) || strings.HasPrefix(err.Error(), domain.ErrAccessTokenUndefined) {
-			delivery.RespondWithError(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-		delivery.RespondWithError(w, http.StatusInternalServerError, domain.ErrLogout)
+	if err := h.usersService.Logout(r.Context(), principal.UserID, principal.SessionID); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.DeleteCookie(w)
+}
+
+func (h UsersHandler) logoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	if err := h.usersService.LogoutAll(r.Context(), principal.UserID); err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	delivery.DeleteCookie(w)
+}
+
+func (h UsersHandler) changePasswordHandler(w http.ResponseWriter, r *http.Request, passwordChange dto.PasswordChangeDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	err := h.usersService.ChangePassword(r.Context(), principal.UserID, principal.SessionID, passwordChange, delivery.ClientFromRequest(r))
+	if err != nil {
+		delivery.RespondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h UsersHandler) deleteUserHandler(w http.ResponseWriter, r *http.Request, userDelete dto.UserDeleteDto) {
+	principal := middleware.PrincipalFromContext(r.Context())
+
+	if err := h.usersService.DeleteUser(r.Context(), principal.UserID, userDelete); err != nil {
+		delivery.RespondWithError(w, err)
 		return
 	}
 
 	delivery.DeleteCookie(w)
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"notes-service-go/internal/service"
+	"notes-service-go/pkg/auth"
+	"notes-service-go/pkg/ratelimit"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeUsers implements service.Users with the methods the tests need, calling
+// any other method panics.
+type fakeUsers struct {
+	service.Users
+
+	refresh func(refreshToken string) (dto.UserResponseDto, string, error)
+	login   func(userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error)
+	logout  func(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+}
+
+func (f *fakeUsers) Refresh(ctx context.Context, refreshToken string, client domain.Client) (dto.UserResponseDto, string, error) {
+	return f.refresh(refreshToken)
+}
+
+func (f *fakeUsers) Login(ctx context.Context, userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error) {
+	return f.login(userCredentials, client)
+}
+
+func (f *fakeUsers) Logout(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	return f.logout(ctx, userID, sessionID)
+}
+
+// newTestRateLimits limits every route group to limit, keeping the buckets in
+// memory.
+func newTestRateLimits(limit ratelimit.Limit) RateLimits {
+	return RateLimits{
+		Limiter:   ratelimit.NewLimiter(ratelimit.NewMemoryStore()),
+		Default:   limit,
+		Auth:      limit,
+		Search:    limit,
+		NoteWrite: limit,
+	}
+}
+
+func newTestUsersHandler(usersService service.Users, tokenManager auth.TokenManager) http.Handler {
+	rateLimits := newTestRateLimits(ratelimit.Limit{Requests: 100, Period: time.Minute})
+	return NewUsersHandler(usersService, tokenManager, validator.New(), time.Hour, testTimeouts, rateLimits).usersHandlers()
+}
+
+func TestRefreshHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		cookie     string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantCookie string
+		wantExpiry bool
+	}{
+		{
+			name:       "rotated",
+			cookie:     "old",
+			wantStatus: http.StatusOK,
+			wantCookie: "new",
+		},
+		{
+			name:       "no cookie",
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "refresh_token_undefined",
+		},
+		{
+			name:       "invalid token keeps the cookie",
+			cookie:     "old",
+			err:        domain.ErrInvalidRefreshToken,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "invalid_refresh_token",
+		},
+		{
+			name:       "reused token deletes the cookie",
+			cookie:     "old",
+			err:        domain.ErrRefreshTokenReused,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "refresh_token_reused",
+			wantExpiry: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{
+				refresh: func(refreshToken string) (dto.UserResponseDto, string, error) {
+					if refreshToken != tt.cookie {
+						t.Errorf("Refresh got token %q, want %q", refreshToken, tt.cookie)
+					}
+					if tt.err != nil {
+						return dto.UserResponseDto{}, "", tt.err
+					}
+					return dto.UserResponseDto{}, "new", nil
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/refresh", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			newTestUsersHandler(users, nil).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", rec.Body, tt.wantCode)
+			}
+
+			cookies := rec.Result().Cookies()
+			switch {
+			case tt.wantCookie != "":
+				if len(cookies) != 1 || cookies[0].Value != tt.wantCookie || !cookies[0].Expires.After(time.Now()) {
+					t.Errorf("cookies = %v, want a live %q cookie", cookies, tt.wantCookie)
+				}
+			case tt.wantExpiry:
+				if len(cookies) != 1 || cookies[0].Value != "" || !cookies[0].Expires.Before(time.Now()) {
+					t.Errorf("cookies = %v, want an expired cookie", cookies)
+				}
+			default:
+				if len(cookies) != 0 {
+					t.Errorf("cookies = %v, want none", cookies)
+				}
+			}
+		})
+	}
+}
+
+func TestLogoutHandlerRevokesAccessToken(t *testing.T) {
+	accessKeys, err := auth.NewLegacyKeySet("access")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenManager := auth.NewManager(time.Minute, time.Hour, accessKeys, "refresh", auth.ClaimsConfig{Issuer: "notes", Audience: "notes"}, nil, auth.NewMemoryRevocationStore())
+	userID, sessionID := uuid.New(), uuid.New()
+	accessToken, accessTokenID, err := tokenManager.NewAccessToken(userID, sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	users := &fakeUsers{
+		logout: func(ctx context.Context, gotUserID uuid.UUID, gotSessionID uuid.UUID) error {
+			calls++
+			if gotUserID != userID || gotSessionID != sessionID {
+				t.Errorf("Logout got user %s session %s, want user %s session %s", gotUserID, gotSessionID, userID, sessionID)
+			}
+			return tokenManager.RevokeAccessToken(ctx, accessTokenID)
+		},
+	}
+	handler := newTestUsersHandler(users, tokenManager)
+
+	logout := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := logout(); rec.Code != http.StatusOK {
+		t.Fatalf("first logout status = %d, want %d, body %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	rec := logout()
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), `"code":"access_token_revoked"`) {
+		t.Errorf("second logout status = %d, body %s, want 401 access_token_revoked", rec.Code, rec.Body)
+	}
+	if calls != 1 {
+		t.Errorf("Logout called %d times, want 1", calls)
+	}
+}
+
+func TestLoginHandlerLockout(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantCode       string
+		wantRetryAfter string
+	}{
+		{
+			name:       "logged in",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wrong credentials",
+			err:        domain.ErrWrongCredentials,
+			wantStatus: http.StatusUnauthorized,
+			wantCode:   "wrong_credentials",
+		},
+		{
+			name:           "locked out",
+			err:            domain.ErrTooManyLoginAttempts.WithRetryAfter(1500 * time.Millisecond),
+			wantStatus:     http.StatusTooManyRequests,
+			wantCode:       "too_many_login_attempts",
+			wantRetryAfter: "2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{
+				login: func(userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error) {
+					if userCredentials.Login != "someone" || client.IP != "192.0.2.1" {
+						t.Errorf("Login got login %q from %q, want %q from %q", userCredentials.Login, client.IP, "someone", "192.0.2.1")
+					}
+					if tt.err != nil {
+						return dto.UserResponseDto{}, "", tt.err
+					}
+					return dto.UserResponseDto{}, "refresh", nil
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"login":"someone","password":"secret password"}`))
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			newTestUsersHandler(users, nil).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want code %q", rec.Body, tt.wantCode)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestLoginHandlerRateLimit(t *testing.T) {
+	users := &fakeUsers{
+		login: func(userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error) {
+			return dto.UserResponseDto{}, "refresh", nil
+		},
+	}
+	rateLimits := newTestRateLimits(ratelimit.Limit{Requests: 2, Period: time.Minute})
+	handler := NewUsersHandler(users, nil, validator.New(), time.Hour, testTimeouts, rateLimits).usersHandlers()
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		wantStatus     int
+		wantCode       string
+		wantRemaining  string
+		wantReset      string
+		wantRetryAfter string
+	}{
+		{
+			name:          "first request",
+			remoteAddr:    "192.0.2.1:1234",
+			wantStatus:    http.StatusOK,
+			wantRemaining: "1",
+			wantReset:     "30",
+		},
+		{
+			name:          "last token",
+			remoteAddr:    "192.0.2.1:1234",
+			wantStatus:    http.StatusOK,
+			wantRemaining: "0",
+			wantReset:     "60",
+		},
+		{
+			name:           "limited",
+			remoteAddr:     "192.0.2.1:1234",
+			wantStatus:     http.StatusTooManyRequests,
+			wantCode:       "rate_limit_exceeded",
+			wantRemaining:  "0",
+			wantReset:      "60",
+			wantRetryAfter: "30",
+		},
+		{
+			name:          "another address has its own bucket",
+			remoteAddr:    "192.0.2.2:1234",
+			wantStatus:    http.StatusOK,
+			wantRemaining: "1",
+			wantReset:     "30",
+		},
+	}
+
+	// The cases share the handler, so they run in order and can't be run
+	// separately.
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"login":"someone","password":"secret password"}`))
+		req.RemoteAddr = tt.remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d, body %s", tt.name, rec.Code, tt.wantStatus, rec.Body)
+		}
+		if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+			t.Errorf("%s: body = %s, want code %q", tt.name, rec.Body, tt.wantCode)
+		}
+
+		headers := map[string]string{
+			"X-RateLimit-Limit":     "2",
+			"X-RateLimit-Remaining": tt.wantRemaining,
+			"X-RateLimit-Reset":     tt.wantReset,
+			"Retry-After":           tt.wantRetryAfter,
+		}
+		for header, want := range headers {
+			if got := rec.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, header, got, want)
+			}
+		}
+	}
+}
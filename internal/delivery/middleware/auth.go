@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/auth"
+	"time"
+)
+
+type principalKey struct{}
+
+// Authenticate parses the access token from the Authorization header and
+// stores the principal it belongs to in the request context. Requests without
+// a valid token are rejected. The check whether the token is revoked may query
+// the database, so it's bounded by timeout, as the routes behind Authenticate
+// set their own deadlines only after it.
+func Authenticate(tokenManager auth.TokenManager, timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			principal, err := parsePrincipal(ctx, tokenManager, r.Header.Get("Authorization"))
+			cancel()
+			if err != nil {
+				delivery.RespondWithError(w, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+		})
+	}
+}
+
+// PrincipalFromContext returns the principal stored by Authenticate. It's the
+// zero Principal for routes that aren't behind Authenticate.
+func PrincipalFromContext(ctx context.Context) domain.Principal {
+	principal, _ := ctx.Value(principalKey{}).(domain.Principal)
+	return principal
+}
+
+func parsePrincipal(ctx context.Context, tokenManager auth.TokenManager, accessToken string) (domain.Principal, error) {
+	claims, err := tokenManager.ParseAccessToken(ctx, accessToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrAccessTokenUndefined):
+			return domain.Principal{}, domain.ErrAccessTokenUndefined
+		case errors.Is(err, auth.ErrAccessTokenRevoked):
+			return domain.Principal{}, domain.ErrAccessTokenRevoked
+		case errors.Is(err, auth.ErrCheckingRevocation):
+			return domain.Principal{}, domain.ErrCheckingAccessToken.Wrap(err)
+		}
+		return domain.Principal{}, domain.ErrInvalidAccessToken.Wrap(err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return domain.Principal{}, domain.ErrInvalidAccessToken.Wrap(err)
+	}
+
+	sessionID := uuid.Nil
+	if claims.SessionID != "" {
+		sessionID, err = uuid.Parse(claims.SessionID)
+		if err != nil {
+			return domain.Principal{}, domain.ErrInvalidAccessToken.Wrap(err)
+		}
+	}
+
+	return domain.Principal{UserID: userID, SessionID: sessionID, Scopes: claims.Scopes()}, nil
+}
@@ -2,26 +2,38 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/go-playground/validator/v10"
-	"log"
+	"github.com/google/uuid"
 	"net/http"
 	"notes-service-go/internal/delivery"
 	"notes-service-go/internal/delivery/dto"
 	"notes-service-go/internal/domain"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultNotesLimit = 50
+	defaultNotesSort  = "created_at"
+	defaultNotesOrder = "desc"
+	defaultTagMode    = "all"
+
+	defaultSearchLimit = 20
+
+	currentRevision = "current"
 )
 
 func CheckUserCredentialsInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.UserCredentialsDto)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userCredentials := dto.UserCredentialsDto{}
 		if err := json.NewDecoder(r.Body).Decode(&userCredentials); err != nil {
-			log.Printf(domain.ErrParsingUserCredentialsInput+" :%s\n", err)
-			delivery.RespondWithError(w, http.StatusBadRequest, domain.ErrParsingUserCredentialsInput)
+			delivery.RespondWithError(w, domain.ErrParsingUserCredentialsInput.Wrap(err))
 			return
 		}
 
 		if err := v.Struct(&userCredentials); err != nil {
-			log.Printf(domain.ErrInvalidUserCredentialsInput+" :%s\n", err)
-			delivery.RespondWithError(w, http.StatusBadRequest, domain.ErrInvalidUserCredentialsInput)
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidUserCredentialsInput, err))
 			return
 		}
 
@@ -29,21 +41,407 @@ func CheckUserCredentialsInput(v *validator.Validate, next func(http.ResponseWri
 	}
 }
 
+func CheckPasswordChangeInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.PasswordChangeDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		passwordChange := dto.PasswordChangeDto{}
+		if err := json.NewDecoder(r.Body).Decode(&passwordChange); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingPasswordChangeInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&passwordChange); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidPasswordChangeInput, err))
+			return
+		}
+
+		next(w, r, passwordChange)
+	}
+}
+
+func CheckUserDeleteInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.UserDeleteDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userDelete := dto.UserDeleteDto{}
+		if err := json.NewDecoder(r.Body).Decode(&userDelete); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingUserDeleteInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&userDelete); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidUserDeleteInput, err))
+			return
+		}
+
+		next(w, r, userDelete)
+	}
+}
+
+func CheckEmailInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.EmailInputDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		emailInput := dto.EmailInputDto{}
+		if err := json.NewDecoder(r.Body).Decode(&emailInput); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingEmailInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&emailInput); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidEmailInput, err))
+			return
+		}
+
+		next(w, r, emailInput)
+	}
+}
+
+func CheckEmailVerifyInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.EmailVerifyDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		emailVerify := dto.EmailVerifyDto{}
+		if err := json.NewDecoder(r.Body).Decode(&emailVerify); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingEmailVerifyInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&emailVerify); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidEmailVerifyInput, err))
+			return
+		}
+
+		next(w, r, emailVerify)
+	}
+}
+
+func CheckPasswordResetRequestInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.PasswordResetRequestDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		passwordResetRequest := dto.PasswordResetRequestDto{}
+		if err := json.NewDecoder(r.Body).Decode(&passwordResetRequest); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingPasswordResetRequestInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&passwordResetRequest); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidPasswordResetRequestInput, err))
+			return
+		}
+
+		next(w, r, passwordResetRequest)
+	}
+}
+
+func CheckPasswordResetInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.PasswordResetDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		passwordReset := dto.PasswordResetDto{}
+		if err := json.NewDecoder(r.Body).Decode(&passwordReset); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingPasswordResetInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&passwordReset); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidPasswordResetInput, err))
+			return
+		}
+
+		next(w, r, passwordReset)
+	}
+}
+
+func CheckTwoFactorCodeInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.TwoFactorCodeDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		twoFactorCode := dto.TwoFactorCodeDto{}
+		if err := json.NewDecoder(r.Body).Decode(&twoFactorCode); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingTwoFactorCodeInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&twoFactorCode); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidTwoFactorCodeInput, err))
+			return
+		}
+
+		next(w, r, twoFactorCode)
+	}
+}
+
+func CheckTwoFactorDisableInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.TwoFactorDisableDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		twoFactorDisable := dto.TwoFactorDisableDto{}
+		if err := json.NewDecoder(r.Body).Decode(&twoFactorDisable); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingTwoFactorDisableInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&twoFactorDisable); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidTwoFactorDisableInput, err))
+			return
+		}
+
+		next(w, r, twoFactorDisable)
+	}
+}
+
+func CheckLoginChallengeInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.LoginChallengeDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loginChallenge := dto.LoginChallengeDto{}
+		if err := json.NewDecoder(r.Body).Decode(&loginChallenge); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingLoginChallengeInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&loginChallenge); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidLoginChallengeInput, err))
+			return
+		}
+
+		next(w, r, loginChallenge)
+	}
+}
+
 func CheckNoteInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.NoteInputDto)) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		noteInput := dto.NoteInputDto{}
 		if err := json.NewDecoder(r.Body).Decode(&noteInput); err != nil {
-			log.Printf(domain.ErrParsingNoteInput+" :%s\n", err)
-			delivery.RespondWithError(w, http.StatusBadRequest, domain.ErrParsingNoteInput)
+			delivery.RespondWithError(w, domain.ErrParsingNoteInput.Wrap(err))
 			return
 		}
 
 		if err := v.Struct(&noteInput); err != nil {
-			log.Printf(domain.ErrInvalidNoteInput+" :%s\n", err)
-			delivery.RespondWithError(w, http.StatusBadRequest, domain.ErrInvalidNoteInput)
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidNoteInput, err))
 			return
 		}
 
 		next(w, r, noteInput)
 	}
 }
+
+func CheckNotePatchInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.NotePatchDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		notePatch := dto.NotePatchDto{}
+		if err := json.NewDecoder(r.Body).Decode(&notePatch); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingNoteInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&notePatch); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidNotePatchInput, err))
+			return
+		}
+
+		next(w, r, notePatch)
+	}
+}
+
+func CheckNotesQuery(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.NotesQueryDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		notesQuery := dto.NotesQueryDto{
+			Limit:   defaultNotesLimit,
+			Cursor:  query.Get("cursor"),
+			Sort:    defaultNotesSort,
+			Order:   defaultNotesOrder,
+			Tags:    query["tag"],
+			TagMode: defaultTagMode,
+		}
+
+		if limit := query.Get("limit"); limit != "" {
+			parsedLimit, err := strconv.ParseInt(limit, 10, 32)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingNotesQuery.Wrap(err))
+				return
+			}
+			notesQuery.Limit = int32(parsedLimit)
+		}
+
+		if sort := query.Get("sort"); sort != "" {
+			notesQuery.Sort = sort
+		}
+
+		if order := query.Get("order"); order != "" {
+			notesQuery.Order = order
+		}
+
+		if notebookID := query.Get("notebook_id"); notebookID != "" {
+			parsedNotebookID, err := uuid.Parse(notebookID)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingNotesQuery.Wrap(err))
+				return
+			}
+			notesQuery.NotebookID = parsedNotebookID
+		}
+
+		if tagMode := query.Get("tag_mode"); tagMode != "" {
+			notesQuery.TagMode = tagMode
+		}
+
+		if excerpt := query.Get("excerpt"); excerpt != "" {
+			parsedExcerpt, err := strconv.ParseBool(excerpt)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingNotesQuery.Wrap(err))
+				return
+			}
+			notesQuery.Excerpt = parsedExcerpt
+		}
+
+		if updatedSince := query.Get("updated_since"); updatedSince != "" {
+			parsedUpdatedSince, err := time.Parse(time.RFC3339, updatedSince)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingNotesQuery.Wrap(err))
+				return
+			}
+			notesQuery.UpdatedSince = parsedUpdatedSince
+		}
+
+		if err := v.Struct(&notesQuery); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidNotesQuery, err))
+			return
+		}
+
+		next(w, r, notesQuery)
+	}
+}
+
+func CheckNotesSearchQuery(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.NotesSearchQueryDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		searchQuery := dto.NotesSearchQueryDto{
+			Query: query.Get("q"),
+			Limit: defaultSearchLimit,
+		}
+
+		if limit := query.Get("limit"); limit != "" {
+			parsedLimit, err := strconv.ParseInt(limit, 10, 32)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingSearchQuery.Wrap(err))
+				return
+			}
+			searchQuery.Limit = int32(parsedLimit)
+		}
+
+		if offset := query.Get("offset"); offset != "" {
+			parsedOffset, err := strconv.ParseInt(offset, 10, 32)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingSearchQuery.Wrap(err))
+				return
+			}
+			searchQuery.Offset = int32(parsedOffset)
+		}
+
+		if err := v.Struct(&searchQuery); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidSearchQuery, err))
+			return
+		}
+
+		next(w, r, searchQuery)
+	}
+}
+
+func CheckNoteRevisionsDiffQuery(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.NoteRevisionsDiffQueryDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("from") == "" {
+			delivery.RespondWithError(w, domain.ErrInvalidRevisionsDiff)
+			return
+		}
+
+		diffQuery := dto.NoteRevisionsDiffQueryDto{}
+
+		from, err := parseRevisionQuery(query.Get("from"))
+		if err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingRevisionsDiff.Wrap(err))
+			return
+		}
+		diffQuery.From = from
+
+		if to := query.Get("to"); to != "" {
+			diffQuery.To, err = parseRevisionQuery(to)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrParsingRevisionsDiff.Wrap(err))
+				return
+			}
+		}
+
+		if err = v.Struct(&diffQuery); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidRevisionsDiff, err))
+			return
+		}
+
+		next(w, r, diffQuery)
+	}
+}
+
+// parseRevisionQuery parses a revision number, "current" is parsed as 0.
+func parseRevisionQuery(value string) (int32, error) {
+	if value == currentRevision {
+		return 0, nil
+	}
+
+	revision, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	if revision < 1 {
+		return 0, domain.ErrInvalidRevision
+	}
+
+	return int32(revision), nil
+}
+
+func CheckTagInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.TagInputDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagInput := dto.TagInputDto{}
+		if err := json.NewDecoder(r.Body).Decode(&tagInput); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingTagInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&tagInput); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidTagInput, err))
+			return
+		}
+
+		next(w, r, tagInput)
+	}
+}
+
+func CheckNoteMoveInput(next func(http.ResponseWriter, *http.Request, dto.NoteMoveDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		noteMove := dto.NoteMoveDto{}
+		if err := json.NewDecoder(r.Body).Decode(&noteMove); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingNoteMoveInput.Wrap(err))
+			return
+		}
+
+		next(w, r, noteMove)
+	}
+}
+
+func CheckNotebookInput(v *validator.Validate, next func(http.ResponseWriter, *http.Request, dto.NotebookInputDto)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		notebookInput := dto.NotebookInputDto{}
+		if err := json.NewDecoder(r.Body).Decode(&notebookInput); err != nil {
+			delivery.RespondWithError(w, domain.ErrParsingNotebookInput.Wrap(err))
+			return
+		}
+
+		if err := v.Struct(&notebookInput); err != nil {
+			delivery.RespondWithError(w, validationError(domain.ErrInvalidNotebookInput, err))
+			return
+		}
+
+		next(w, r, notebookInput)
+	}
+}
+
+// validationError adds the fields that failed validation and their rules to
+// the details of e.
+func validationError(e *domain.Error, err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return e.Wrap(err)
+	}
+
+	details := make([]dto.FieldErrorDto, len(validationErrors))
+	for i, fieldError := range validationErrors {
+		details[i] = dto.FieldErrorDto{Field: fieldError.Field(), Rule: fieldError.Tag(), Param: fieldError.Param()}
+	}
+
+	return e.WithDetails(details).Wrap(err)
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"math"
+	"net/http"
+	"notes-service-go/internal/delivery"
+	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/ratelimit"
+	"strconv"
+)
+
+// RateLimit limits the requests of every user to limit, anonymous requests
+// are limited per IP address. It has to run after Authenticate to tell users
+// apart. Routes with different policies don't share buckets, so a user who
+// used up the limit of one policy can still make requests limited by another.
+// Every response carries the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers, the latter is the number of seconds until the
+// bucket is full again.
+func RateLimit(limiter *ratelimit.Limiter, policy string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := limiter.Allow(r.Context(), rateLimitKey(r, policy), limit)
+			if err != nil {
+				delivery.RespondWithError(w, domain.ErrCheckingRateLimit.Wrap(err))
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+
+			if !result.Allowed {
+				delivery.RespondWithError(w, domain.ErrRateLimited.WithRetryAfter(result.RetryAfter))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitKey(r *http.Request, policy string) string {
+	principal := PrincipalFromContext(r.Context())
+	if principal.UserID != uuid.Nil {
+		return policy + ":user:" + principal.UserID.String()
+	}
+
+	return policy + ":ip:" + delivery.ClientFromRequest(r).IP
+}
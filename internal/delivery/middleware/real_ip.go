@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP replaces the remote address of requests made through one of the
+// trusted proxies with the address of the client they were forwarded for, so
+// that the login lockout, the rate limits and the sessions see the client
+// rather than the proxy. X-Forwarded-For is read from the right and the first
+// address that isn't a trusted proxy is taken, X-Real-IP is used without
+// X-Forwarded-For.
+// The headers of requests made directly are ignored, as the client could put
+// anything in them.
+func RealIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedFor(r, trustedProxies); ok {
+				r.RemoteAddr = ip.String()
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedFor(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !isTrustedProxy(peer.Addr(), trustedProxies) {
+		return netip.Addr{}, false
+	}
+
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return netip.Addr{}, false
+			}
+
+			client = ip.Unmap()
+			if !isTrustedProxy(client, trustedProxies) {
+				break
+			}
+		}
+
+		return client, true
+	}
+
+	ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return ip.Unmap(), true
+}
+
+func isTrustedProxy(ip netip.Addr, trustedProxies []netip.Prefix) bool {
+	ip = ip.Unmap()
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trustedProxies := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::1/128"),
+	}
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		forwardedFor   []string
+		realIP         string
+		wantRemoteAddr string
+	}{
+		{
+			name:           "direct request ignores headers",
+			remoteAddr:     "192.0.2.1:1234",
+			forwardedFor:   []string{"198.51.100.1"},
+			realIP:         "198.51.100.2",
+			wantRemoteAddr: "192.0.2.1:1234",
+		},
+		{
+			name:           "trusted proxy without headers",
+			remoteAddr:     "10.0.0.1:1234",
+			wantRemoteAddr: "10.0.0.1:1234",
+		},
+		{
+			name:           "forwarded for",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"198.51.100.1"},
+			wantRemoteAddr: "198.51.100.1",
+		},
+		{
+			name:           "spoofed hops left of the client are skipped",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"203.0.113.7, 198.51.100.1", "10.0.0.2"},
+			wantRemoteAddr: "198.51.100.1",
+		},
+		{
+			name:           "only trusted hops",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"10.0.0.3, 10.0.0.2"},
+			wantRemoteAddr: "10.0.0.3",
+		},
+		{
+			name:           "malformed forwarded for",
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"unknown"},
+			wantRemoteAddr: "10.0.0.1:1234",
+		},
+		{
+			name:           "real ip",
+			remoteAddr:     "[2001:db8::1]:1234",
+			realIP:         "2001:db8::2",
+			wantRemoteAddr: "2001:db8::2",
+		},
+		{
+			name:           "ipv4-mapped address",
+			remoteAddr:     "[::ffff:10.0.0.1]:1234",
+			forwardedFor:   []string{"::ffff:198.51.100.1"},
+			wantRemoteAddr: "198.51.100.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.wantRemoteAddr {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.wantRemoteAddr)
+			}
+		})
+	}
+}
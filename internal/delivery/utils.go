@@ -1,12 +1,22 @@
 package delivery
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
 	"log"
+	"math"
 	"net/http"
+	"notes-service-go/internal/domain"
+	"strconv"
 	"time"
 )
 
+// queryCanceled is the code of the Postgres error a query cancelled because of
+// its context ends with.
+const queryCanceled = "57014"
+
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	data, err := json.Marshal(payload)
 
@@ -21,16 +31,79 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(data)
 }
 
-func RespondWithError(w http.ResponseWriter, code int, msg string) {
+// RespondWithError logs err and responds with the status, code, message and
+// details of the domain error in its chain, its RetryAfter is sent in the
+// Retry-After header. Errors caused by the request deadline are answered with
+// 504, see isTimeout, other errors with 500 without exposing their text.
+func RespondWithError(w http.ResponseWriter, err error) {
 	type errResponse struct {
-		Error string `json:"error"`
+		Code    string `json:"code"`
+		Error   string `json:"error"`
+		Details any    `json:"details,omitempty"`
+	}
+
+	log.Println(err)
+
+	var domainErr *domain.Error
+	switch {
+	case isTimeout(err):
+		domainErr = domain.ErrRequestTimeout
+	case !errors.As(err, &domainErr):
+		domainErr = domain.ErrInternalServer
 	}
 
-	RespondWithJSON(w, code, errResponse{
-		Error: msg,
+	if domainErr.RetryAfter > 0 {
+		SetRetryAfter(w, domainErr.RetryAfter)
+	}
+
+	RespondWithJSON(w, errorStatus(domainErr), errResponse{
+		Code:    domainErr.Code,
+		Error:   domainErr.Message,
+		Details: domainErr.Details,
 	})
 }
 
+// isTimeout reports whether err is caused by the request deadline. lib/pq
+// doesn't return the error of the context, a query it cancels fails with a
+// query_canceled error instead.
+func isTimeout(err error) bool {
+	var pqErr *pq.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &pqErr) && pqErr.Code == queryCanceled
+}
+
+func errorStatus(err *domain.Error) int {
+	switch err.Kind {
+	case domain.ErrNotFound:
+		return http.StatusNotFound
+	case domain.ErrUnauthorized:
+		return http.StatusUnauthorized
+	case domain.ErrForbidden:
+		return http.StatusForbidden
+	case domain.ErrValidation:
+		return http.StatusBadRequest
+	case domain.ErrConflict:
+		return http.StatusConflict
+	case domain.ErrSpellingRejected:
+		return http.StatusUnprocessableEntity
+	case domain.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case domain.ErrPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case domain.ErrTimeout:
+		return http.StatusGatewayTimeout
+	case domain.ErrTooManyRequests:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// SetRetryAfter sets the Retry-After header in whole seconds, rounded up so
+// that clients don't retry too early.
+func SetRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
 func SetCookie(w http.ResponseWriter, refreshToken string, refreshTTL time.Duration) {
 	cookie := http.Cookie{
 		Name:     "refresh_token",
@@ -0,0 +1,8 @@
+package domain
+
+// Client describes the device a request comes from. It's shown to users in
+// the list of their sessions.
+type Client struct {
+	UserAgent string
+	IP        string
+}
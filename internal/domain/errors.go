@@ -1,45 +1,335 @@
 package domain
 
-const (
-	ErrUndefinedEnvParam = "parameter is undefined"
-	ErrParsingAccessTTL  = "error parsing access ttl"
-	ErrParsingRefreshTTL = "error parsing refresh ttl"
+import (
+	"errors"
+	"time"
 )
 
 const (
-	ErrGettingRefreshTokenFromCookie = "error getting refresh token from cookie"
-	ErrCreatingRefreshToken          = "error creating refresh token"
-	ErrCreatingAccessToken           = "error creating access token"
-	ErrSavingRefreshToken            = "error saving refresh token to db"
-	ErrGettingRefreshTokenFromDB     = "error getting refresh token by user id from db"
-	ErrInvalidRefreshToken           = "invalid refresh token"
-	ErrInvalidAccessToken            = "invalid access token"
-	ErrRefreshTokenUndefined         = "refresh token is undefined"
-	ErrAccessTokenUndefined          = "access token is undefined"
+	ErrUndefinedEnvParam           = "parameter is undefined"
+	ErrParsingAccessTTL            = "error parsing access ttl"
+	ErrParsingRefreshTTL           = "error parsing refresh ttl"
+	ErrParsingTrashRetention       = "error parsing trash retention"
+	ErrParsingRequestTimeout       = "error parsing request timeout"
+	ErrParsingSearchTimeout        = "error parsing search timeout"
+	ErrParsingNoteWriteTimeout     = "error parsing note write timeout"
+	ErrParsingTokenLeeway          = "error parsing token leeway"
+	ErrInvalidRevocationStore      = "REVOCATION_STORE must be memory or postgres"
+	ErrParsingPasswordResetTTL     = "error parsing password reset ttl"
+	ErrParsingEmailVerifyTTL       = "error parsing email verify ttl"
+	ErrInvalidMailer               = "MAILER must be smtp, file or log"
+	ErrParsingAccessSigningKeys    = "ACCESS_SIGNING_KEYS must be a comma-separated list of kid:path pairs"
+	ErrInvalidLoginLockoutStore    = "LOGIN_LOCKOUT_STORE must be memory or postgres"
+	ErrParsingLoginMaxFailures     = "error parsing login max failures"
+	ErrParsingLoginIPMaxFailures   = "error parsing login ip max failures"
+	ErrParsingLoginLockoutBase     = "error parsing login lockout base"
+	ErrParsingLoginLockoutMax      = "error parsing login lockout max"
+	ErrParsingLoginFailureTTL      = "error parsing login failure ttl"
+	ErrParsingTrustedProxies       = "TRUSTED_PROXIES must be a comma-separated list of IP addresses and CIDR ranges"
+	ErrInvalidRateLimitStore       = "RATE_LIMIT_STORE must be memory or postgres"
+	ErrParsingDefaultRateLimit     = "error parsing default rate limit"
+	ErrParsingAuthRateLimit        = "error parsing auth rate limit"
+	ErrParsingSearchRateLimit      = "error parsing search rate limit"
+	ErrParsingNoteWriteRateLimit   = "error parsing note write rate limit"
+	ErrParsingArgon2Memory         = "error parsing argon2 memory"
+	ErrParsingArgon2Iterations     = "error parsing argon2 iterations"
+	ErrParsingArgon2Parallelism    = "error parsing argon2 parallelism"
+	ErrParsingLoginMinLength       = "error parsing login min length"
+	ErrParsingLoginMaxLength       = "error parsing login max length"
+	ErrParsingPasswordMinLength    = "error parsing password min length"
+	ErrParsingPasswordMaxLength    = "error parsing password max length"
+	ErrParsingPasswordMinClasses   = "PASSWORD_MIN_CLASSES must be a number from 0 to 4"
+	ErrParsingPasswordRejectLogin  = "error parsing password reject login"
+	ErrParsingPasswordRejectCommon = "error parsing password reject common"
 )
 
-const (
-	ErrParsingUserCredentialsInput = "error parsing user credentials"
-	ErrInvalidUserCredentialsInput = "invalid user credentials input(login and password must be at least 6 characters long and can't be empty)"
-	ErrCheckingUserExist           = "error checking user exist"
-	ErrUserAlreadyExists           = "user with this login already exists"
-	ErrHashingPassword             = "error hashing password"
-	ErrCreatingUser                = "error creating user"
-	ErrGettingPassword             = "error getting password by login from db"
-	ErrUserNotFound                = "user does not exist"
-	ErrWrongPassword               = "wrongPassword"
-	ErrWrongCredentials            = "error wrong credentials(login or password)"
-	ErrLogin                       = "login error"
-	ErrLogout                      = "logout error"
-	ErrRefresh                     = "refresh error"
+// Kinds of errors. Every Error has one of them as its Kind, it decides the
+// HTTP status of the response.
+var (
+	ErrNotFound             = errors.New("not found")
+	ErrUnauthorized         = errors.New("unauthorized")
+	ErrForbidden            = errors.New("forbidden")
+	ErrValidation           = errors.New("validation error")
+	ErrConflict             = errors.New("conflict")
+	ErrSpellingRejected     = errors.New("spelling rejected")
+	ErrPreconditionFailed   = errors.New("precondition failed")
+	ErrPreconditionRequired = errors.New("precondition required")
+	ErrTimeout              = errors.New("timeout")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrInternal             = errors.New("internal error")
 )
 
-const (
-	ErrParsingNoteInput       = "error parsing note input"
-	ErrInvalidNoteInput       = "invalid note input(both 'name' and 'content' fields are required and can't be empty)"
-	ErrParsingID              = "error parsing id"
-	ErrGettingNotes           = "error getting notes"
-	ErrCreatingNote           = "error creating note"
-	ErrCheckingSpellingErrors = "error checking spelling errors"
-	ErrSpellingText           = "error spelling text"
+var (
+	ErrInternalServer    = Internal("internal server error")
+	ErrRequestTimeout    = Timeout("request_timeout", "request took too long to process")
+	ErrRateLimited       = TooManyRequests("rate_limit_exceeded", "too many requests, try again later")
+	ErrCheckingRateLimit = Internal("error checking rate limit")
+)
+
+var (
+	ErrGettingRefreshTokenFromCookie = Unauthorized("refresh_token_unreadable", "error getting refresh token from cookie")
+	ErrCreatingRefreshToken          = Internal("error creating refresh token")
+	ErrHashingRefreshToken           = Internal("error hashing refresh token")
+	ErrCreatingAccessToken           = Internal("error creating access token")
+	ErrInvalidRefreshToken           = Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrInvalidAccessToken            = Unauthorized("invalid_access_token", "invalid access token")
+	ErrRefreshTokenUndefined         = Unauthorized("refresh_token_undefined", "refresh token is undefined")
+	ErrAccessTokenUndefined          = Unauthorized("access_token_undefined", "access token is undefined")
+	ErrAccessTokenRevoked            = Unauthorized("access_token_revoked", "access token has been revoked, log in again")
+	ErrCheckingAccessToken           = Internal("error checking access token")
+	ErrRevokingAccessToken           = Internal("error revoking access token")
+)
+
+var (
+	ErrParsingUserCredentialsInput = Validation("malformed_user_credentials", "error parsing user credentials")
+	ErrInvalidUserCredentialsInput = Validation("invalid_user_credentials", "invalid user credentials input(both 'login' and 'password' fields are required)")
+	ErrCheckingUserExist           = Internal("error checking user exist")
+	ErrUserAlreadyExists           = Conflict("user_already_exists", "user with this login already exists")
+	ErrCredentialsPolicyViolation  = Validation("credentials_policy_violation", "login or password doesn't meet the policy, the broken rules are in details")
+	ErrHashingPassword             = Internal("error hashing password")
+	ErrCreatingUser                = Internal("error creating user")
+	ErrGettingPassword             = Internal("error getting password by login from db")
+	ErrWrongCredentials            = Unauthorized("wrong_credentials", "error wrong credentials(login or password)")
+	ErrTooManyLoginAttempts        = TooManyRequests("too_many_login_attempts", "too many failed login attempts, try again later")
+	ErrCheckingLoginAttempts       = Internal("error checking failed login attempts")
+	ErrRehashingPassword           = Internal("error rehashing password")
+	ErrLogin                       = Internal("login error")
+	ErrLogout                      = Internal("logout error")
+	ErrRefresh                     = Internal("refresh error")
+	ErrParsingPasswordChangeInput  = Validation("malformed_password_change_input", "error parsing password change input")
+	ErrInvalidPasswordChangeInput  = Validation("invalid_password_change_input", "invalid password change input(both 'current_password' and 'new_password' fields are required)")
+	ErrParsingUserDeleteInput      = Validation("malformed_user_delete_input", "error parsing user delete input")
+	ErrInvalidUserDeleteInput      = Validation("invalid_user_delete_input", "invalid user delete input('password' field is required)")
+	ErrUserNotFound                = NotFound("user_not_found", "user not found")
+	ErrWrongPassword               = Forbidden("wrong_password", "wrong password")
+	ErrChangingPassword            = Internal("error changing password")
+	ErrDeletingUser                = Internal("error deleting user")
+	ErrGettingUser                 = Internal("error getting user")
+	ErrSendingMail                 = Internal("error sending mail")
+)
+
+var (
+	ErrParsingEmailInput                = Validation("malformed_email_input", "error parsing email input")
+	ErrInvalidEmailInput                = Validation("invalid_email_input", "invalid email input('email' field is required and must be a valid email address)")
+	ErrParsingEmailVerifyInput          = Validation("malformed_email_verify_input", "error parsing email verify input")
+	ErrInvalidEmailVerifyInput          = Validation("invalid_email_verify_input", "invalid email verify input('token' field is required)")
+	ErrInvalidEmailVerifyToken          = Validation("invalid_email_verify_token", "email verification token is invalid, expired or has already been used")
+	ErrEmailAlreadyUsed                 = Conflict("email_already_used", "email is already used by another user")
+	ErrSettingEmail                     = Internal("error setting email")
+	ErrVerifyingEmail                   = Internal("error verifying email")
+	ErrParsingPasswordResetRequestInput = Validation("malformed_password_reset_request_input", "error parsing password reset request input")
+	ErrInvalidPasswordResetRequestInput = Validation("invalid_password_reset_request_input", "invalid password reset request input('email' field is required and must be a valid email address)")
+	ErrParsingPasswordResetInput        = Validation("malformed_password_reset_input", "error parsing password reset input")
+	ErrInvalidPasswordResetInput        = Validation("invalid_password_reset_input", "invalid password reset input(both 'token' and 'new_password' fields are required)")
+	ErrInvalidPasswordResetToken        = Validation("invalid_password_reset_token", "password reset token is invalid, expired or has already been used")
+	ErrRequestingPasswordReset          = Internal("error requesting password reset")
+	ErrResettingPassword                = Internal("error resetting password")
+)
+
+var (
+	ErrParsingTwoFactorCodeInput    = Validation("malformed_two_factor_code_input", "error parsing two-factor code input")
+	ErrInvalidTwoFactorCodeInput    = Validation("invalid_two_factor_code_input", "invalid two-factor code input('code' field is required)")
+	ErrParsingTwoFactorDisableInput = Validation("malformed_two_factor_disable_input", "error parsing two-factor disable input")
+	ErrInvalidTwoFactorDisableInput = Validation("invalid_two_factor_disable_input", "invalid two-factor disable input(both 'password' and 'code' fields are required)")
+	ErrParsingLoginChallengeInput   = Validation("malformed_login_challenge_input", "error parsing login challenge input")
+	ErrInvalidLoginChallengeInput   = Validation("invalid_login_challenge_input", "invalid login challenge input(both 'challenge_token' and 'code' fields are required)")
+	ErrTwoFactorAlreadyEnabled      = Conflict("two_factor_already_enabled", "two-factor authentication is already enabled")
+	ErrTwoFactorNotSetUp            = Conflict("two_factor_not_set_up", "two-factor authentication hasn't been set up")
+	ErrTwoFactorNotEnabled          = Conflict("two_factor_not_enabled", "two-factor authentication isn't enabled")
+	ErrWrongTwoFactorCode           = Unauthorized("wrong_two_factor_code", "wrong two-factor authentication code")
+	ErrInvalidLoginChallenge        = Unauthorized("invalid_login_challenge", "login challenge is invalid, expired or has too many failed attempts, log in again")
+	ErrSettingUpTwoFactor           = Internal("error setting up two-factor authentication")
+	ErrEnablingTwoFactor            = Internal("error enabling two-factor authentication")
+	ErrDisablingTwoFactor           = Internal("error disabling two-factor authentication")
+	ErrCreatingLoginChallenge       = Internal("error creating login challenge")
+	ErrCompletingLogin              = Internal("error completing login")
 )
+
+var (
+	ErrInvalidSessionID   = Validation("invalid_session_id", "invalid session id")
+	ErrSessionNotFound    = NotFound("session_not_found", "session not found")
+	ErrCreatingSession    = Internal("error creating session")
+	ErrGettingSession     = Internal("error getting session")
+	ErrRotatingSession    = Internal("error rotating session")
+	ErrGettingSessions    = Internal("error getting sessions")
+	ErrDeletingSession    = Internal("error deleting session")
+	ErrRevokingSession    = Internal("error revoking session")
+	ErrRefreshTokenReused = Unauthorized("refresh_token_reused", "refresh token has already been used, the session has been revoked, log in again")
+)
+
+var (
+	ErrParsingNoteInput       = Validation("malformed_note_input", "error parsing note input")
+	ErrInvalidNoteInput       = Validation("invalid_note_input", "invalid note input(both 'name' and 'content' fields are required and can't be empty, 'content' must be at most 100000 characters long, at most 20 tags of up to 64 characters are allowed)")
+	ErrInvalidNotePatchInput  = Validation("invalid_note_patch_input", "invalid note patch input(at least one of 'name', 'content' and 'tags' fields is required, 'name' and 'content' can't be empty, 'content' must be at most 100000 characters long)")
+	ErrParsingNotesQuery      = Validation("malformed_notes_query", "error parsing notes query")
+	ErrInvalidNotesQuery      = Validation("invalid_notes_query", "invalid notes query(limit must be between 1 and 100, sort must be one of created_at, updated_at, name, order must be asc or desc and tag_mode must be all or any)")
+	ErrInvalidCursor          = Validation("invalid_cursor", "invalid cursor")
+	ErrParsingSearchQuery     = Validation("malformed_search_query", "error parsing search query")
+	ErrInvalidSearchQuery     = Validation("invalid_search_query", "invalid search query(q is required and must be at most 256 characters long, limit must be between 1 and 100)")
+	ErrSearchingNotes         = Internal("error searching notes")
+	ErrInvalidNoteID          = Validation("invalid_note_id", "invalid note id")
+	ErrNoteNotFound           = NotFound("note_not_found", "note not found")
+	ErrGettingNotes           = Internal("error getting notes")
+	ErrGettingNote            = Internal("error getting note")
+	ErrCreatingNote           = Internal("error creating note")
+	ErrUpdatingNote           = Internal("error updating note")
+	ErrDeletingNote           = Internal("error deleting note")
+	ErrCheckingSpellingErrors = Internal("error checking spelling errors")
+	ErrSpellingText           = SpellingRejected("spelling_errors", "error spelling text")
+	ErrGettingNoteTags        = Internal("error getting note tags")
+	ErrParsingNoteMoveInput   = Validation("malformed_note_move_input", "error parsing note move input")
+	ErrMovingNote             = Internal("error moving note")
+	ErrNoteVersionRequired    = PreconditionRequired("note_version_required", "If-Match header with the note version is required")
+	ErrNoteVersionMismatch    = PreconditionFailed("note_version_mismatch", "note has been modified, its version doesn't match If-Match header")
+)
+
+var (
+	ErrDeletedNoteNotFound = NotFound("deleted_note_not_found", "note not found in trash")
+	ErrGettingTrash        = Internal("error getting trash")
+	ErrRestoringNote       = Internal("error restoring note")
+	ErrEmptyingTrash       = Internal("error emptying trash")
+	ErrPurgingTrash        = Internal("error purging trash")
+)
+
+var (
+	ErrInvalidRevision       = Validation("invalid_revision", "invalid revision(revision must be a positive number)")
+	ErrParsingRevisionsDiff  = Validation("malformed_revisions_diff_query", "error parsing revisions diff query")
+	ErrInvalidRevisionsDiff  = Validation("invalid_revisions_diff_query", "invalid revisions diff query(from is required, from and to must be positive revision numbers or current)")
+	ErrNoteRevisionNotFound  = NotFound("note_revision_not_found", "note revision not found")
+	ErrGettingNoteRevisions  = Internal("error getting note revisions")
+	ErrGettingNoteRevision   = Internal("error getting note revision")
+	ErrDiffingNoteRevisions  = Internal("error diffing note revisions")
+	ErrRestoringNoteRevision = Internal("error restoring note revision")
+)
+
+var (
+	ErrParsingNotebookInput   = Validation("malformed_notebook_input", "error parsing notebook input")
+	ErrInvalidNotebookInput   = Validation("invalid_notebook_input", "invalid notebook input('name' field is required and must be at most 128 characters long)")
+	ErrInvalidNotebookID      = Validation("invalid_notebook_id", "invalid notebook id")
+	ErrInvalidNotebookDelete  = Validation("invalid_notebook_delete_mode", "invalid notebook delete mode(mode must be cascade or move_to_root)")
+	ErrNotebookNotFound       = NotFound("notebook_not_found", "notebook not found")
+	ErrParentNotebookNotFound = Validation("parent_notebook_not_found", "parent notebook not found")
+	ErrNotebookCycle          = Validation("notebook_cycle", "notebook can't be moved into itself or its descendants")
+	ErrGettingNotebooks       = Internal("error getting notebooks")
+	ErrGettingNotebook        = Internal("error getting notebook")
+	ErrCreatingNotebook       = Internal("error creating notebook")
+	ErrUpdatingNotebook       = Internal("error updating notebook")
+	ErrDeletingNotebook       = Internal("error deleting notebook")
+)
+
+var (
+	ErrParsingTagInput = Validation("malformed_tag_input", "error parsing tag input")
+	ErrInvalidTagInput = Validation("invalid_tag_input", "invalid tag input('name' field is required and must be at most 64 characters long)")
+	ErrInvalidTagID    = Validation("invalid_tag_id", "invalid tag id")
+	ErrTagNotFound     = NotFound("tag_not_found", "tag not found")
+	ErrGettingTags     = Internal("error getting tags")
+	ErrUpdatingTag     = Internal("error updating tag")
+	ErrDeletingTag     = Internal("error deleting tag")
+)
+
+// Error is an error that can be shown to clients. Code is a stable
+// machine-readable code, Message and Details are returned to clients as they
+// are. RetryAfter tells clients when to repeat the request, if it's set. Err
+// is the underlying cause, it's only logged.
+type Error struct {
+	Kind       error
+	Code       string
+	Message    string
+	Details    any
+	RetryAfter time.Duration
+	Err        error
+}
+
+func NotFound(code string, message string) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: message}
+}
+
+func Unauthorized(code string, message string) *Error {
+	return &Error{Kind: ErrUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code string, message string) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: message}
+}
+
+func Validation(code string, message string) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: message}
+}
+
+func Conflict(code string, message string) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: message}
+}
+
+func SpellingRejected(code string, message string) *Error {
+	return &Error{Kind: ErrSpellingRejected, Code: code, Message: message}
+}
+
+func PreconditionFailed(code string, message string) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Code: code, Message: message}
+}
+
+func PreconditionRequired(code string, message string) *Error {
+	return &Error{Kind: ErrPreconditionRequired, Code: code, Message: message}
+}
+
+func Timeout(code string, message string) *Error {
+	return &Error{Kind: ErrTimeout, Code: code, Message: message}
+}
+
+func TooManyRequests(code string, message string) *Error {
+	return &Error{Kind: ErrTooManyRequests, Code: code, Message: message}
+}
+
+func Internal(message string) *Error {
+	return &Error{Kind: ErrInternal, Code: "internal_error", Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of e or the error e was made from with
+// Wrap, WithDetails or WithRetryAfter.
+func (e *Error) Is(target error) bool {
+	if target == e.Kind {
+		return true
+	}
+
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code && t.Message == e.Message
+}
+
+// Wrap returns a copy of e caused by err. If err already is an Error it's
+// returned unchanged, so that an error produced deeper keeps its kind.
+func (e *Error) Wrap(err error) error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithDetails returns a copy of e with details for clients.
+func (e *Error) WithDetails(details any) *Error {
+	detailed := *e
+	detailed.Details = details
+	return &detailed
+}
+
+// WithRetryAfter returns a copy of e that tells clients to repeat the request
+// after d.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	limited := *e
+	limited.RetryAfter = d
+	return &limited
+}
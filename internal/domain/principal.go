@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated user a request is made on behalf of.
+// SessionID is uuid.Nil when the access token isn't bound to a session.
+type Principal struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	Scopes    []string
+}
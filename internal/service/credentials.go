@@ -0,0 +1,44 @@
+package service
+
+import (
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/policy"
+)
+
+// checkCredentials checks the normalized login and the password of a new user
+// against LoginPolicy and PasswordPolicy. All broken rules of both fields are
+// returned at once, so that they can be fixed in one go.
+func (s *UsersService) checkCredentials(login string, password string) error {
+	details := append(
+		violationDetails("login", s.LoginPolicy.Check(login)),
+		violationDetails("password", s.PasswordPolicy.Check(password, login))...,
+	)
+	if len(details) != 0 {
+		return domain.ErrCredentialsPolicyViolation.WithDetails(details)
+	}
+
+	return nil
+}
+
+// checkNewPassword checks the new password of the user with the login against
+// PasswordPolicy.
+func (s *UsersService) checkNewPassword(login string, password string) error {
+	details := violationDetails("new_password", s.PasswordPolicy.Check(password, login))
+	if len(details) != 0 {
+		return domain.ErrCredentialsPolicyViolation.WithDetails(details)
+	}
+
+	return nil
+}
+
+// violationDetails describes the violations the same way as validation
+// errors of the request body are described.
+func violationDetails(field string, violations []policy.Violation) []dto.FieldErrorDto {
+	details := make([]dto.FieldErrorDto, len(violations))
+	for i, violation := range violations {
+		details[i] = dto.FieldErrorDto{Field: field, Rule: violation.Rule, Param: violation.Param}
+	}
+
+	return details
+}
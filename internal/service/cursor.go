@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/domain"
+	"time"
+)
+
+const (
+	sortByName      = "name"
+	sortByCreatedAt = "created_at"
+	sortByUpdatedAt = "updated_at"
+)
+
+// notesCursor points at the last note of a page. The sort key and order are
+// stored alongside the position so a cursor can't be replayed against a
+// differently sorted listing.
+type notesCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Name  string    `json:"n,omitempty"`
+	Time  time.Time `json:"t,omitempty"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func newNotesCursor(note database.Note, sort string, order string) notesCursor {
+	cursor := notesCursor{Sort: sort, Order: order, ID: note.ID}
+
+	switch sort {
+	case sortByName:
+		cursor.Name = note.Name
+	case sortByCreatedAt:
+		cursor.Time = note.CreatedAt
+	case sortByUpdatedAt:
+		cursor.Time = note.UpdatedAt
+	}
+
+	return cursor
+}
+
+func (c notesCursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeNotesCursor(encoded string, sort string, order string) (notesCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return notesCursor{}, domain.ErrInvalidCursor
+	}
+
+	var cursor notesCursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return notesCursor{}, domain.ErrInvalidCursor
+	}
+
+	if cursor.Sort != sort || cursor.Order != order || cursor.ID == uuid.Nil {
+		return notesCursor{}, domain.ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+)
+
+const uniqueViolation = "23505"
+
+// SetEmail sets an unverified email of the user and mails a verification
+// token to it. Password reset mail is only sent to verified emails. Password
+// reset tokens already mailed to the previous email are deleted, whoever
+// controls that mailbox can't reset the password anymore.
+func (s *UsersService) SetEmail(ctx context.Context, userID uuid.UUID, emailInput dto.EmailInputDto) error {
+	var token string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		err := repo.SetUserEmail(ctx, database.SetUserEmailParams{ID: userID, Email: sql.NullString{String: emailInput.Email, Valid: true}})
+		if err != nil {
+			return err
+		}
+
+		err = repo.DeleteUserTokens(ctx, database.DeleteUserTokensParams{UserID: userID, Purpose: UserTokenPasswordReset})
+		if err != nil {
+			return err
+		}
+
+		token, err = s.newUserToken(ctx, repo, userID, UserTokenEmailVerify, emailInput.Email, s.EmailVerifyTTL)
+		return err
+	})
+	if err != nil {
+		return domain.ErrSettingEmail.Wrap(err)
+	}
+
+	s.sendMail(ctx, emailVerifyMessage(emailInput.Email, token, s.EmailVerifyTTL))
+
+	return nil
+}
+
+// VerifyEmail verifies the email the token has been mailed to. The token is
+// rejected when the user has changed the email since.
+func (s *UsersService) VerifyEmail(ctx context.Context, emailVerify dto.EmailVerifyDto) error {
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		verifyToken, err := s.consumeUserToken(ctx, repo, emailVerify.Token, UserTokenEmailVerify)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrInvalidEmailVerifyToken
+			}
+			return err
+		}
+
+		verified, err := repo.VerifyUserEmail(ctx, database.VerifyUserEmailParams{
+			ID:    verifyToken.UserID,
+			Email: sql.NullString{String: verifyToken.Email, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		if verified == 0 {
+			return domain.ErrInvalidEmailVerifyToken
+		}
+
+		return nil
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrEmailAlreadyUsed
+		}
+		return domain.ErrVerifyingEmail.Wrap(err)
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether err is caused by a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"notes-service-go/internal/database"
+	"time"
+)
+
+// PostgresLockoutStore keeps failed login attempts in the login_failures
+// table, so that all instances of the service see them.
+type PostgresLockoutStore struct {
+	Repo *database.Queries
+}
+
+func NewPostgresLockoutStore(repo *database.Queries) *PostgresLockoutStore {
+	return &PostgresLockoutStore{
+		Repo: repo,
+	}
+}
+
+func (s *PostgresLockoutStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	lockedUntil, err := s.Repo.GetLoginLockout(ctx, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+
+	return lockedUntil.Time, nil
+}
+
+func (s *PostgresLockoutStore) AddFailure(ctx context.Context, key string, expiresAt time.Time) (int, error) {
+	failures, err := s.Repo.AddLoginFailure(ctx, database.AddLoginFailureParams{Key: key, ExpiresAt: expiresAt})
+	return int(failures), err
+}
+
+func (s *PostgresLockoutStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.Repo.LockLoginFailures(ctx, database.LockLoginFailuresParams{LockedUntil: until, Key: key})
+}
+
+func (s *PostgresLockoutStore) Reset(ctx context.Context, key string) error {
+	return s.Repo.DeleteLoginFailures(ctx, key)
+}
+
+func (s *PostgresLockoutStore) DeleteExpired(ctx context.Context) error {
+	_, err := s.Repo.DeleteExpiredLoginFailures(ctx)
+	return err
+}
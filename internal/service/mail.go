@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/mail"
+	"time"
+)
+
+const mailTimeout = 30 * time.Second
+
+// sendMail sends the message in the background. The response doesn't wait
+// for the mail server, so its timing doesn't tell whether mail has been sent
+// at all. Errors are only logged.
+func (s *UsersService) sendMail(ctx context.Context, message mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+		defer cancel()
+
+		if err := s.Mailer.Send(ctx, message); err != nil {
+			log.Println(domain.ErrSendingMail.Wrap(err))
+		}
+	}()
+}
+
+func passwordResetMessage(email string, token string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Someone has requested a password reset for your account.\n\n"+
+			"To set a new password, send this token to POST /users/password/reset within %s:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email, your password stays the same.\n", ttl, token),
+	}
+}
+
+func emailVerifyMessage(email string, token string, ttl time.Duration) mail.Message {
+	return mail.Message{
+		To:      email,
+		Subject: "Email verification",
+		Body: fmt.Sprintf("This email has been added to your account.\n\n"+
+			"To verify it, send this token to POST /users/email/verify within %s:\n\n%s\n\n"+
+			"If it wasn't you, ignore this email.\n", ttl, token),
+	}
+}
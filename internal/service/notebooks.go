@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"time"
+)
+
+const (
+	// NotebookDeleteCascade moves the notes of the notebook and of all its
+	// nested notebooks to the trash.
+	NotebookDeleteCascade = "cascade"
+	// NotebookDeleteMoveToRoot keeps the notes and moves them out of any notebook.
+	NotebookDeleteMoveToRoot = "move_to_root"
+)
+
+type NotebooksService struct {
+	DB   *sql.DB
+	Repo *database.Queries
+}
+
+func NewNotebooksService(db *sql.DB, repo *database.Queries) *NotebooksService {
+	return &NotebooksService{
+		DB:   db,
+		Repo: repo,
+	}
+}
+
+func (s *NotebooksService) GetNotebooks(ctx context.Context, userID uuid.UUID) ([]dto.NotebookResponseDto, error) {
+	notebooks, err := s.Repo.ListNotebooks(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrGettingNotebooks.Wrap(err)
+	}
+
+	dtos := make([]dto.NotebookResponseDto, len(notebooks))
+	for i, notebook := range notebooks {
+		dtos[i] = s.newNotebookResponseDto(notebook)
+	}
+
+	return dtos, nil
+}
+
+func (s *NotebooksService) GetNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID) (dto.NotebookResponseDto, error) {
+	notebook, err := s.Repo.GetNotebook(ctx, database.GetNotebookParams{ID: notebookID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NotebookResponseDto{}, domain.ErrNotebookNotFound
+		}
+		return dto.NotebookResponseDto{}, domain.ErrGettingNotebook.Wrap(err)
+	}
+
+	return s.newNotebookResponseDto(notebook), nil
+}
+
+func (s *NotebooksService) CreateNotebook(ctx context.Context, userID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error) {
+	parentID, err := s.checkParent(ctx, s.Repo, userID, uuid.Nil, notebookInput.ParentID)
+	if err != nil {
+		return dto.NotebookResponseDto{}, domain.ErrCreatingNotebook.Wrap(err)
+	}
+
+	notebook, err := s.Repo.CreateNotebook(ctx, database.CreateNotebookParams{Name: notebookInput.Name, UserID: userID, ParentID: parentID})
+	if err != nil {
+		return dto.NotebookResponseDto{}, domain.ErrCreatingNotebook.Wrap(err)
+	}
+
+	return s.newNotebookResponseDto(notebook), nil
+}
+
+func (s *NotebooksService) UpdateNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error) {
+	var notebook database.Notebook
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		parentID, err := s.checkParent(ctx, repo, userID, notebookID, notebookInput.ParentID)
+		if err != nil {
+			return err
+		}
+
+		notebook, err = repo.UpdateNotebook(ctx, database.UpdateNotebookParams{
+			ID:       notebookID,
+			UserID:   userID,
+			Name:     notebookInput.Name,
+			ParentID: parentID,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NotebookResponseDto{}, domain.ErrNotebookNotFound
+		}
+		return dto.NotebookResponseDto{}, domain.ErrUpdatingNotebook.Wrap(err)
+	}
+
+	return s.newNotebookResponseDto(notebook), nil
+}
+
+// DeleteNotebook deletes a notebook together with its nested notebooks. With
+// NotebookDeleteCascade their notes are moved to the trash, with
+// NotebookDeleteMoveToRoot the notes are kept and moved to the root.
+func (s *NotebooksService) DeleteNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID, mode string) error {
+	var deleted int64
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		if mode == NotebookDeleteCascade {
+			if err := repo.TrashNotebookSubtreeNotes(ctx, database.TrashNotebookSubtreeNotesParams{RootID: notebookID, UserID: userID}); err != nil {
+				return err
+			}
+		}
+
+		// notes.notebook_id is ON DELETE SET NULL, so whatever notes are left
+		// in the subtree end up in the root.
+		var err error
+		deleted, err = repo.DeleteNotebook(ctx, database.DeleteNotebookParams{ID: notebookID, UserID: userID})
+		return err
+	})
+	if err != nil {
+		return domain.ErrDeletingNotebook.Wrap(err)
+	}
+
+	if deleted == 0 {
+		return domain.ErrNotebookNotFound
+	}
+
+	return nil
+}
+
+// checkParent verifies that parentID belongs to the user and, for an existing
+// notebook, that it isn't the notebook itself or one of its descendants. The
+// notebook and the chain of the new parent up to the root are locked until the
+// end of the transaction first, otherwise two concurrent moves could each pass
+// the check and make a cycle together, e.g. moving A into B and B into A.
+func (s *NotebooksService) checkParent(ctx context.Context, repo *database.Queries, userID uuid.UUID, notebookID uuid.UUID, parentID *uuid.UUID) (uuid.NullUUID, error) {
+	if parentID == nil {
+		return uuid.NullUUID{}, nil
+	}
+
+	if _, err := repo.GetNotebook(ctx, database.GetNotebookParams{ID: *parentID, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.NullUUID{}, domain.ErrParentNotebookNotFound
+		}
+		return uuid.NullUUID{}, err
+	}
+
+	if notebookID != uuid.Nil {
+		err := repo.LockNotebookChain(ctx, database.LockNotebookChainParams{ParentID: *parentID, NotebookID: notebookID})
+		if err != nil {
+			return uuid.NullUUID{}, err
+		}
+
+		inSubtree, err := repo.IsNotebookInSubtree(ctx, database.IsNotebookInSubtreeParams{RootID: notebookID, NotebookID: *parentID})
+		if err != nil {
+			return uuid.NullUUID{}, err
+		}
+		if inSubtree {
+			return uuid.NullUUID{}, domain.ErrNotebookCycle
+		}
+	}
+
+	return uuid.NullUUID{UUID: *parentID, Valid: true}, nil
+}
+
+func (s *NotebooksService) newNotebookResponseDto(notebook database.Notebook) dto.NotebookResponseDto {
+	return dto.NotebookResponseDto{
+		ID:        notebook.ID,
+		Name:      notebook.Name,
+		ParentID:  nullUUIDPtr(notebook.ParentID),
+		CreatedAt: notebook.CreatedAt,
+		UpdatedAt: notebook.UpdatedAt,
+	}
+}
+
+func nullUUIDPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
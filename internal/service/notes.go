@@ -2,98 +2,432 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/google/uuid"
 	"notes-service-go/internal/database"
 	"notes-service-go/internal/delivery/dto"
 	"notes-service-go/internal/domain"
-	"notes-service-go/pkg/auth"
 	"notes-service-go/pkg/spell"
 )
 
+// AnyNoteVersion makes a conditional note update or delete skip the version
+// check.
+const AnyNoteVersion int32 = 0
+
 type NotesService struct {
-	Repo         *database.Queries
-	Speller      spell.Speller
-	TokenManager auth.TokenManager
+	DB      *sql.DB
+	Repo    *database.Queries
+	Speller spell.Speller
 }
 
-func NewNotesService(repo *database.Queries, speller spell.Speller, tokenManager auth.TokenManager) *NotesService {
+func NewNotesService(db *sql.DB, repo *database.Queries, speller spell.Speller) *NotesService {
 	return &NotesService{
-		Repo:         repo,
-		Speller:      speller,
-		TokenManager: tokenManager,
+		DB:      db,
+		Repo:    repo,
+		Speller: speller,
 	}
 }
 
-func (s *NotesService) GetNotes(accessToken string) ([]dto.NoteResponseDto, error) {
-	userIDStr, err := s.TokenManager.ParseAccessToken(accessToken)
+func (s *NotesService) GetNotes(ctx context.Context, userID uuid.UUID, notesQuery dto.NotesQueryDto) (dto.NotesPageDto, error) {
+	params := database.ListNotesParams{
+		UserID:    userID,
+		SortBy:    notesQuery.Sort,
+		SortOrder: notesQuery.Order,
+		Tags:      normalizeTags(notesQuery.Tags),
+		TagMode:   notesQuery.TagMode,
+		RowLimit:  notesQuery.Limit + 1,
+	}
+
+	if !notesQuery.UpdatedSince.IsZero() {
+		params.UpdatedSince = sql.NullTime{Time: notesQuery.UpdatedSince, Valid: true}
+	}
+
+	if notesQuery.NotebookID != uuid.Nil {
+		params.NotebookID = uuid.NullUUID{UUID: notesQuery.NotebookID, Valid: true}
+	}
+
+	if notesQuery.Cursor != "" {
+		cursor, err := decodeNotesCursor(notesQuery.Cursor, notesQuery.Sort, notesQuery.Order)
+		if err != nil {
+			return dto.NotesPageDto{}, err
+		}
+		params.HasCursor = true
+		params.CursorName = cursor.Name
+		params.CursorTime = cursor.Time
+		params.CursorID = cursor.ID
+	}
+
+	notes, err := s.Repo.ListNotes(ctx, params)
 	if err != nil {
-		if err.Error() == domain.ErrAccessTokenUndefined {
-			return nil, err
+		return dto.NotesPageDto{}, domain.ErrGettingNotes.Wrap(err)
+	}
+
+	page := dto.NotesPageDto{}
+
+	if len(notes) > int(notesQuery.Limit) {
+		notes = notes[:notesQuery.Limit]
+		page.NextCursor, err = newNotesCursor(notes[len(notes)-1], notesQuery.Sort, notesQuery.Order).encode()
+		if err != nil {
+			return dto.NotesPageDto{}, domain.ErrGettingNotes.Wrap(err)
 		}
-		return nil, errors.New(domain.ErrInvalidAccessToken)
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	notesTags, err := s.getNotesTags(ctx, notes)
+	if err != nil {
+		return dto.NotesPageDto{}, domain.ErrGettingNoteTags.Wrap(err)
+	}
+
+	page.Notes = s.newNotesResponseDto(notes, notesTags, notesQuery.Excerpt)
+
+	return page, nil
+}
+
+func (s *NotesService) GetNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) (dto.NoteResponseDto, error) {
+	note, err := s.Repo.GetNote(ctx, database.GetNoteParams{ID: noteID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteResponseDto{}, domain.ErrNoteNotFound
+		}
+		return dto.NoteResponseDto{}, domain.ErrGettingNote.Wrap(err)
+	}
+
+	tags, err := s.Repo.GetNoteTags(ctx, note.ID)
+	if err != nil {
+		return dto.NoteResponseDto{}, domain.ErrGettingNoteTags.Wrap(err)
+	}
+
+	return s.newNoteResponseDto(note, tags), nil
+}
+
+func (s *NotesService) SearchNotes(ctx context.Context, userID uuid.UUID, searchQuery dto.NotesSearchQueryDto) ([]dto.NoteSearchResultDto, error) {
+	results, err := s.Repo.SearchNotes(ctx, database.SearchNotesParams{
+		Query:     searchQuery.Query,
+		UserID:    userID,
+		RowLimit:  searchQuery.Limit,
+		RowOffset: searchQuery.Offset,
+	})
+	if err != nil {
+		return nil, domain.ErrSearchingNotes.Wrap(err)
+	}
+
+	return s.newSearchResultsDto(results), nil
+}
+
+func (s *NotesService) CreateNote(ctx context.Context, userID uuid.UUID, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error) {
+	err := s.checkSpelling(ctx, noteInput.Content)
 	if err != nil {
-		return nil, fmt.Errorf(domain.ErrParsingID+" :%s\n", err)
+		return dto.NoteResponseDto{}, err
 	}
 
-	notes, err := s.Repo.GetNotes(context.Background(), userID)
+	var note database.Note
+	var tags []string
+
+	err = inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		note, err = repo.CreateNote(ctx, database.CreateNoteParams{Name: noteInput.Name, Content: noteInput.Content, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		tags, err = s.setNoteTags(ctx, repo, userID, note.ID, noteInput.Tags)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf(domain.ErrGettingNotes+" :%s\n", err)
+		return dto.NoteResponseDto{}, domain.ErrCreatingNote.Wrap(err)
 	}
 
-	return s.newNotesResponseDto(notes), nil
+	return s.newNoteResponseDto(note, tags), nil
 }
 
-func (s *NotesService) CreateNote(noteInput dto.NoteInputDto, accessToken string) (dto.NoteResponseDto, error) {
-	userIDStr, err := s.TokenManager.ParseAccessToken(accessToken)
+func (s *NotesService) UpdateNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error) {
+	if err := s.checkSpelling(ctx, noteInput.Content); err != nil {
+		return dto.NoteResponseDto{}, err
+	}
+
+	var note database.Note
+	var tags []string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		current, err := repo.GetNoteForUpdate(ctx, database.GetNoteForUpdateParams{ID: noteID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		if err = checkNoteVersion(current, versions); err != nil {
+			return err
+		}
+
+		if err = s.saveRevision(ctx, repo, current, noteInput.Name, noteInput.Content); err != nil {
+			return err
+		}
+
+		note, err = repo.UpdateNote(ctx, database.UpdateNoteParams{ID: noteID, UserID: userID, Name: noteInput.Name, Content: noteInput.Content})
+		if err != nil {
+			return err
+		}
+
+		tags, err = s.setNoteTags(ctx, repo, userID, note.ID, noteInput.Tags)
+		return err
+	})
 	if err != nil {
-		if err.Error() == domain.ErrAccessTokenUndefined {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteResponseDto{}, domain.ErrNoteNotFound
+		}
+		return dto.NoteResponseDto{}, domain.ErrUpdatingNote.Wrap(err)
+	}
+
+	return s.newNoteResponseDto(note, tags), nil
+}
+
+func (s *NotesService) PatchNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, notePatch dto.NotePatchDto) (dto.NoteResponseDto, error) {
+	params := database.PatchNoteParams{ID: noteID, UserID: userID}
+
+	if notePatch.Name != nil {
+		params.Name = sql.NullString{String: *notePatch.Name, Valid: true}
+	}
+
+	if notePatch.Content != nil {
+		if err := s.checkSpelling(ctx, *notePatch.Content); err != nil {
 			return dto.NoteResponseDto{}, err
 		}
-		return dto.NoteResponseDto{}, errors.New(domain.ErrInvalidAccessToken)
+		params.Content = sql.NullString{String: *notePatch.Content, Valid: true}
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	var note database.Note
+	var tags []string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		current, err := repo.GetNoteForUpdate(ctx, database.GetNoteForUpdateParams{ID: noteID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		if err = checkNoteVersion(current, versions); err != nil {
+			return err
+		}
+
+		name, content := current.Name, current.Content
+		if params.Name.Valid {
+			name = params.Name.String
+		}
+		if params.Content.Valid {
+			content = params.Content.String
+		}
+
+		if err = s.saveRevision(ctx, repo, current, name, content); err != nil {
+			return err
+		}
+
+		note, err = repo.PatchNote(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		if notePatch.Tags != nil {
+			tags, err = s.setNoteTags(ctx, repo, userID, note.ID, *notePatch.Tags)
+			return err
+		}
+
+		tags, err = repo.GetNoteTags(ctx, note.ID)
+		return err
+	})
 	if err != nil {
-		return dto.NoteResponseDto{}, fmt.Errorf(domain.ErrParsingID+" :%s\n", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteResponseDto{}, domain.ErrNoteNotFound
+		}
+		return dto.NoteResponseDto{}, domain.ErrUpdatingNote.Wrap(err)
 	}
 
-	spellingErrors, err := s.Speller.CheckText(noteInput.Content)
+	return s.newNoteResponseDto(note, tags), nil
+}
+
+func (s *NotesService) MoveNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, noteMove dto.NoteMoveDto) (dto.NoteResponseDto, error) {
+	params := database.MoveNoteParams{ID: noteID, UserID: userID}
+
+	if noteMove.NotebookID != nil {
+		_, err := s.Repo.GetNotebook(ctx, database.GetNotebookParams{ID: *noteMove.NotebookID, UserID: userID})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return dto.NoteResponseDto{}, domain.ErrNotebookNotFound
+			}
+			return dto.NoteResponseDto{}, domain.ErrMovingNote.Wrap(err)
+		}
+		params.NotebookID = uuid.NullUUID{UUID: *noteMove.NotebookID, Valid: true}
+	}
+
+	var note database.Note
+	var tags []string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		current, err := repo.GetNoteForUpdate(ctx, database.GetNoteForUpdateParams{ID: noteID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		if err = checkNoteVersion(current, versions); err != nil {
+			return err
+		}
+
+		// The notebook may be deleted after it was checked, the foreign key
+		// of the note catches that.
+		note, err = repo.MoveNote(ctx, params)
+		if err != nil {
+			return err
+		}
+
+		tags, err = repo.GetNoteTags(ctx, note.ID)
+		return err
+	})
 	if err != nil {
-		return dto.NoteResponseDto{}, fmt.Errorf(domain.ErrCheckingSpellingErrors+" :%s\n", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteResponseDto{}, domain.ErrNoteNotFound
+		}
+		if isForeignKeyViolation(err) {
+			return dto.NoteResponseDto{}, domain.ErrNotebookNotFound
+		}
+		return dto.NoteResponseDto{}, domain.ErrMovingNote.Wrap(err)
+	}
+
+	return s.newNoteResponseDto(note, tags), nil
+}
+
+// DeleteNote moves a note to the trash.
+func (s *NotesService) DeleteNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32) error {
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		current, err := repo.GetNoteForUpdate(ctx, database.GetNoteForUpdateParams{ID: noteID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		if err = checkNoteVersion(current, versions); err != nil {
+			return err
+		}
+
+		_, err = repo.DeleteNote(ctx, database.DeleteNoteParams{ID: noteID, UserID: userID})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrNoteNotFound
+		}
+		return domain.ErrDeletingNote.Wrap(err)
+	}
+
+	return nil
+}
+
+// checkNoteVersion compares the version of a locked note with the versions a
+// client expects it to have, any of them will do.
+func checkNoteVersion(note database.Note, versions []int32) error {
+	for _, version := range versions {
+		if version == AnyNoteVersion || note.Version == version {
+			return nil
+		}
+	}
+
+	return domain.ErrNoteVersionMismatch
+}
+
+func (s *NotesService) checkSpelling(ctx context.Context, text string) error {
+	spellingErrors, err := s.Speller.CheckText(ctx, text)
+	if err != nil {
+		return domain.ErrCheckingSpellingErrors.Wrap(err)
 	}
 
 	if len(spellingErrors) != 0 {
-		return dto.NoteResponseDto{}, errors.New(domain.ErrSpellingText + ". " + s.Speller.FormatErrors(spellingErrors))
+		spellingErr := domain.ErrSpellingText.WithDetails(spellingErrors)
+		spellingErr.Message += ". " + s.Speller.FormatErrors(spellingErrors)
+		return spellingErr
+	}
+
+	return nil
+}
+
+// setNoteTags replaces the tags of a note, creating the user's tags that don't
+// exist yet, and returns the normalized tag names.
+func (s *NotesService) setNoteTags(ctx context.Context, repo *database.Queries, userID uuid.UUID, noteID uuid.UUID, tags []string) ([]string, error) {
+	names := normalizeTags(tags)
+
+	if err := repo.DeleteNoteTags(ctx, noteID); err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		return names, nil
+	}
+
+	tagIDs, err := repo.UpsertTags(ctx, database.UpsertTagsParams{UserID: userID, Names: names})
+	if err != nil {
+		return nil, err
 	}
 
-	note, err := s.Repo.CreateNote(context.Background(), database.CreateNoteParams{Name: noteInput.Name, Content: noteInput.Content, UserID: userID})
+	if err = repo.AddNoteTags(ctx, database.AddNoteTagsParams{NoteID: noteID, TagIds: tagIDs}); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (s *NotesService) getNotesTags(ctx context.Context, notes []database.Note) (map[uuid.UUID][]string, error) {
+	noteIDs := make([]uuid.UUID, len(notes))
+	for i, note := range notes {
+		noteIDs[i] = note.ID
+	}
+
+	rows, err := s.Repo.GetNotesTags(ctx, noteIDs)
 	if err != nil {
-		return dto.NoteResponseDto{}, fmt.Errorf(domain.ErrCreatingNote+" :%s\n", err)
+		return nil, err
+	}
+
+	notesTags := make(map[uuid.UUID][]string, len(notes))
+	for _, row := range rows {
+		notesTags[row.NoteID] = append(notesTags[row.NoteID], row.Name)
 	}
 
-	return s.newNoteResponseDto(note), nil
+	return notesTags, nil
 }
 
-func (s *NotesService) newNoteResponseDto(note database.CreateNoteRow) dto.NoteResponseDto {
+func (s *NotesService) newNoteResponseDto(note database.Note, tags []string) dto.NoteResponseDto {
+	if tags == nil {
+		tags = []string{}
+	}
+
 	return dto.NoteResponseDto{
-		ID:      note.ID,
-		Name:    note.Name,
-		Content: note.Content,
+		ID:         note.ID,
+		Name:       note.Name,
+		Content:    note.Content,
+		Tags:       tags,
+		NotebookID: nullUUIDPtr(note.NotebookID),
+		Version:    note.Version,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+		DeletedAt:  nullTimePtr(note.DeletedAt),
 	}
 }
 
-func (s *NotesService) newNotesResponseDto(notes []database.GetNotesRow) []dto.NoteResponseDto {
+func (s *NotesService) newNotesResponseDto(notes []database.Note, notesTags map[uuid.UUID][]string, excerpt bool) []dto.NoteResponseDto {
 	dtos := make([]dto.NoteResponseDto, len(notes))
 	for i, note := range notes {
-		dtos[i] = dto.NoteResponseDto{
-			ID:      note.ID,
-			Name:    note.Name,
-			Content: note.Content,
+		dtos[i] = s.newNoteResponseDto(note, notesTags[note.ID])
+		if excerpt {
+			dtos[i].Content = ""
+			dtos[i].Excerpt = newExcerpt(note.Content)
+		}
+	}
+	return dtos
+}
+
+func (s *NotesService) newSearchResultsDto(results []database.SearchNotesRow) []dto.NoteSearchResultDto {
+	dtos := make([]dto.NoteSearchResultDto, len(results))
+	for i, result := range results {
+		dtos[i] = dto.NoteSearchResultDto{
+			ID:            result.ID,
+			Name:          result.Name,
+			NameHighlight: escapeHeadline(result.NameHeadline),
+			Snippet:       escapeHeadline(result.Snippet),
+			Rank:          result.Rank,
+			CreatedAt:     result.CreatedAt,
+			UpdatedAt:     result.UpdatedAt,
 		}
 	}
 	return dtos
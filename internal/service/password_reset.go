@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+)
+
+// RequestPasswordReset mails a password reset token to the user with the
+// verified email. Nothing happens when there's no such user, and the caller
+// can't tell, so that the endpoint can't be used to find out whose email it
+// is.
+func (s *UsersService) RequestPasswordReset(ctx context.Context, passwordResetRequest dto.PasswordResetRequestDto) error {
+	user, err := s.Repo.GetUserByVerifiedEmail(ctx, passwordResetRequest.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return domain.ErrRequestingPasswordReset.Wrap(err)
+	}
+
+	var token string
+
+	err = inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		var err error
+		token, err = s.newUserToken(ctx, repo, user.ID, UserTokenPasswordReset, user.Email.String, s.PasswordResetTTL)
+		return err
+	})
+	if err != nil {
+		return domain.ErrRequestingPasswordReset.Wrap(err)
+	}
+
+	s.sendMail(ctx, passwordResetMessage(user.Email.String, token, s.PasswordResetTTL))
+
+	return nil
+}
+
+// ResetPassword sets a new password with a password reset token. The token
+// can only be used once. All sessions of the user are ended. The new password
+// has to meet PasswordPolicy, otherwise the token stays valid.
+func (s *UsersService) ResetPassword(ctx context.Context, passwordReset dto.PasswordResetDto, client domain.Client) error {
+	var accessTokenIDs []string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		resetToken, err := s.consumeUserToken(ctx, repo, passwordReset.Token, UserTokenPasswordReset)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrInvalidPasswordResetToken
+			}
+			return err
+		}
+
+		// The password can only be checked against the login once the
+		// token tells whose password it is.
+		user, err := repo.GetUser(ctx, resetToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		if err = s.checkNewPassword(user.Login, passwordReset.NewPassword); err != nil {
+			return err
+		}
+
+		hashedPassword, err := s.Hasher.Hash(passwordReset.NewPassword)
+		if err != nil {
+			return domain.ErrHashingPassword.Wrap(err)
+		}
+
+		err = repo.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{ID: resetToken.UserID, Password: hashedPassword})
+		if err != nil {
+			return err
+		}
+
+		accessTokenIDs, err = repo.DeleteUserSessions(ctx, resetToken.UserID)
+		if err != nil {
+			return err
+		}
+
+		return recordSecurityEvent(ctx, repo, SecurityEventPasswordReset, resetToken.UserID, uuid.Nil, client)
+	})
+	if err != nil {
+		return domain.ErrResettingPassword.Wrap(err)
+	}
+
+	return s.revokeAccessTokens(ctx, accessTokenIDs...)
+}
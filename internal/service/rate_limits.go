@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"notes-service-go/internal/database"
+	"notes-service-go/pkg/ratelimit"
+)
+
+// PostgresRateLimitStore keeps token buckets in the rate_limits table, so that
+// all instances of the service share them. A token is taken in a single
+// statement, concurrent requests can't take the same token.
+type PostgresRateLimitStore struct {
+	Repo *database.Queries
+}
+
+func NewPostgresRateLimitStore(repo *database.Queries) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{
+		Repo: repo,
+	}
+}
+
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (float64, bool, error) {
+	row, err := s.Repo.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:           key,
+		Requests:      float64(limit.Requests),
+		PeriodSeconds: limit.Period.Seconds(),
+		Rate:          limit.Rate(),
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return row.Tokens, row.Allowed, nil
+}
+
+func (s *PostgresRateLimitStore) DeleteExpired(ctx context.Context) error {
+	_, err := s.Repo.DeleteExpiredRateLimits(ctx)
+	return err
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/diff"
+	"strconv"
+)
+
+// currentRevision is the label of the current state of a note in diffs.
+const currentRevision = "current"
+
+func (s *NotesService) GetNoteRevisions(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) ([]dto.NoteRevisionResponseDto, error) {
+	if _, err := s.Repo.GetNote(ctx, database.GetNoteParams{ID: noteID, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNoteNotFound
+		}
+		return nil, domain.ErrGettingNoteRevisions.Wrap(err)
+	}
+
+	revisions, err := s.Repo.ListNoteRevisions(ctx, noteID)
+	if err != nil {
+		return nil, domain.ErrGettingNoteRevisions.Wrap(err)
+	}
+
+	dtos := make([]dto.NoteRevisionResponseDto, len(revisions))
+	for i, revision := range revisions {
+		dtos[i] = dto.NoteRevisionResponseDto{Revision: revision.Revision, Name: revision.Name, CreatedAt: revision.CreatedAt}
+	}
+
+	return dtos, nil
+}
+
+func (s *NotesService) GetNoteRevision(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, revision int32) (dto.NoteRevisionResponseDto, error) {
+	if _, err := s.Repo.GetNote(ctx, database.GetNoteParams{ID: noteID, UserID: userID}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteRevisionResponseDto{}, domain.ErrNoteNotFound
+		}
+		return dto.NoteRevisionResponseDto{}, domain.ErrGettingNoteRevision.Wrap(err)
+	}
+
+	noteRevision, err := s.Repo.GetNoteRevision(ctx, database.GetNoteRevisionParams{NoteID: noteID, Revision: revision})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteRevisionResponseDto{}, domain.ErrNoteRevisionNotFound
+		}
+		return dto.NoteRevisionResponseDto{}, domain.ErrGettingNoteRevision.Wrap(err)
+	}
+
+	return s.newNoteRevisionResponseDto(noteRevision), nil
+}
+
+// DiffNoteRevisions returns a unified diff of the note content between two
+// revisions, revision 0 stands for the current state of the note.
+func (s *NotesService) DiffNoteRevisions(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, diffQuery dto.NoteRevisionsDiffQueryDto) (dto.NoteRevisionsDiffDto, error) {
+	note, err := s.Repo.GetNote(ctx, database.GetNoteParams{ID: noteID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteRevisionsDiffDto{}, domain.ErrNoteNotFound
+		}
+		return dto.NoteRevisionsDiffDto{}, domain.ErrDiffingNoteRevisions.Wrap(err)
+	}
+
+	from, err := s.getRevisionState(ctx, note, diffQuery.From)
+	if err != nil {
+		return dto.NoteRevisionsDiffDto{}, err
+	}
+
+	to, err := s.getRevisionState(ctx, note, diffQuery.To)
+	if err != nil {
+		return dto.NoteRevisionsDiffDto{}, err
+	}
+
+	return dto.NoteRevisionsDiffDto{
+		From:     from.label,
+		To:       to.label,
+		FromName: from.name,
+		ToName:   to.name,
+		Diff:     diff.Unified(from.label, to.label, from.content, to.content),
+	}, nil
+}
+
+// RestoreNoteRevision replaces the name and content of a note with the ones of
+// the revision. The replaced state is saved as a new revision, so a restore
+// can be undone like any other update.
+func (s *NotesService) RestoreNoteRevision(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, revision int32, versions []int32) (dto.NoteResponseDto, error) {
+	var note database.Note
+	var tags []string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		current, err := repo.GetNoteForUpdate(ctx, database.GetNoteForUpdateParams{ID: noteID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		if err = checkNoteVersion(current, versions); err != nil {
+			return err
+		}
+
+		noteRevision, err := repo.GetNoteRevision(ctx, database.GetNoteRevisionParams{NoteID: noteID, Revision: revision})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrNoteRevisionNotFound
+			}
+			return err
+		}
+
+		if err = s.saveRevision(ctx, repo, current, noteRevision.Name, noteRevision.Content); err != nil {
+			return err
+		}
+
+		note, err = repo.UpdateNote(ctx, database.UpdateNoteParams{ID: noteID, UserID: userID, Name: noteRevision.Name, Content: noteRevision.Content})
+		if err != nil {
+			return err
+		}
+
+		tags, err = repo.GetNoteTags(ctx, note.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteResponseDto{}, domain.ErrNoteNotFound
+		}
+		return dto.NoteResponseDto{}, domain.ErrRestoringNoteRevision.Wrap(err)
+	}
+
+	return s.newNoteResponseDto(note, tags), nil
+}
+
+// saveRevision keeps the current name and content of a note as a new revision
+// when an update is about to change them. The note must be locked with
+// GetNoteForUpdate so that concurrent updates get consecutive revisions.
+func (s *NotesService) saveRevision(ctx context.Context, repo *database.Queries, current database.Note, name string, content string) error {
+	if current.Name == name && current.Content == content {
+		return nil
+	}
+
+	_, err := repo.CreateNoteRevision(ctx, database.CreateNoteRevisionParams{NoteID: current.ID, Name: current.Name, Content: current.Content})
+	return err
+}
+
+type revisionState struct {
+	label   string
+	name    string
+	content string
+}
+
+func (s *NotesService) getRevisionState(ctx context.Context, note database.Note, revision int32) (revisionState, error) {
+	if revision == 0 {
+		return revisionState{label: currentRevision, name: note.Name, content: note.Content}, nil
+	}
+
+	noteRevision, err := s.Repo.GetNoteRevision(ctx, database.GetNoteRevisionParams{NoteID: note.ID, Revision: revision})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return revisionState{}, domain.ErrNoteRevisionNotFound
+		}
+		return revisionState{}, domain.ErrDiffingNoteRevisions.Wrap(err)
+	}
+
+	return revisionState{label: strconv.Itoa(int(revision)), name: noteRevision.Name, content: noteRevision.Content}, nil
+}
+
+func (s *NotesService) newNoteRevisionResponseDto(noteRevision database.NoteRevision) dto.NoteRevisionResponseDto {
+	return dto.NoteRevisionResponseDto{
+		Revision:  noteRevision.Revision,
+		Name:      noteRevision.Name,
+		Content:   noteRevision.Content,
+		CreatedAt: noteRevision.CreatedAt,
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"notes-service-go/internal/database"
+	"time"
+)
+
+// PostgresRevocationStore keeps revoked token ids in the revoked_tokens table,
+// so that all instances of the service see them.
+type PostgresRevocationStore struct {
+	Repo *database.Queries
+}
+
+func NewPostgresRevocationStore(repo *database.Queries) *PostgresRevocationStore {
+	return &PostgresRevocationStore{
+		Repo: repo,
+	}
+}
+
+func (s *PostgresRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return s.Repo.RevokeToken(ctx, database.RevokeTokenParams{ID: tokenID, ExpiresAt: expiresAt})
+}
+
+func (s *PostgresRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return s.Repo.IsTokenRevoked(ctx, tokenID)
+}
+
+func (s *PostgresRevocationStore) DeleteExpired(ctx context.Context) error {
+	_, err := s.Repo.DeleteExpiredRevokedTokens(ctx)
+	return err
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/domain"
+)
+
+// Types of security events.
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	SecurityEventPasswordChange    = "password_change"
+	SecurityEventPasswordReset     = "password_reset"
+	SecurityEventTwoFactorEnabled  = "two_factor_enabled"
+	SecurityEventTwoFactorDisabled = "two_factor_disabled"
+	SecurityEventRecoveryCodeUsed  = "recovery_code_used"
+)
+
+// recordSecurityEvent saves an event that may be a sign of the account being
+// compromised. sessionID is uuid.Nil when the event isn't tied to a session.
+func recordSecurityEvent(ctx context.Context, repo *database.Queries, eventType string, userID uuid.UUID, sessionID uuid.UUID, client domain.Client) error {
+	return repo.CreateSecurityEvent(ctx, database.CreateSecurityEventParams{
+		UserID:    userID,
+		SessionID: uuid.NullUUID{UUID: sessionID, Valid: sessionID != uuid.Nil},
+		Type:      eventType,
+		UserAgent: client.UserAgent,
+		Ip:        client.IP,
+	})
+}
@@ -1,43 +1,140 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"notes-service-go/internal/database"
 	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
 	"notes-service-go/pkg/auth"
+	"notes-service-go/pkg/crypt"
 	"notes-service-go/pkg/hash"
+	"notes-service-go/pkg/lockout"
+	"notes-service-go/pkg/mail"
+	"notes-service-go/pkg/policy"
 	"notes-service-go/pkg/spell"
+	"time"
 )
 
+const foreignKeyViolation = "23503"
+
 type Users interface {
-	CreateUser(userCredentials dto.UserCredentialsDto) (dto.UserResponseDto, string, error)
-	Refresh(refreshToken string) (dto.UserResponseDto, string, error)
-	Login(userCredentials dto.UserCredentialsDto) (dto.UserResponseDto, string, error)
-	Logout(accessToken string) error
+	CreateUser(ctx context.Context, userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error)
+	Refresh(ctx context.Context, refreshToken string, client domain.Client) (dto.UserResponseDto, string, error)
+	Login(ctx context.Context, userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error)
+	Logout(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+	LogoutAll(ctx context.Context, userID uuid.UUID) error
+	GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID uuid.UUID) ([]dto.SessionResponseDto, error)
+	DeleteSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error
+	ChangePassword(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, passwordChange dto.PasswordChangeDto, client domain.Client) error
+	DeleteUser(ctx context.Context, userID uuid.UUID, userDelete dto.UserDeleteDto) error
+	GetUser(ctx context.Context, userID uuid.UUID) (dto.UserProfileDto, error)
+	SetEmail(ctx context.Context, userID uuid.UUID, emailInput dto.EmailInputDto) error
+	VerifyEmail(ctx context.Context, emailVerify dto.EmailVerifyDto) error
+	RequestPasswordReset(ctx context.Context, passwordResetRequest dto.PasswordResetRequestDto) error
+	ResetPassword(ctx context.Context, passwordReset dto.PasswordResetDto, client domain.Client) error
+	SetupTwoFactor(ctx context.Context, userID uuid.UUID) (dto.TwoFactorSetupDto, error)
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, twoFactorCode dto.TwoFactorCodeDto, client domain.Client) (dto.RecoveryCodesDto, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, twoFactorDisable dto.TwoFactorDisableDto, client domain.Client) error
+	CompleteLogin(ctx context.Context, loginChallenge dto.LoginChallengeDto, client domain.Client) (dto.UserResponseDto, string, error)
 }
 
 type Notes interface {
-	GetNotes(accessToken string) ([]dto.NoteResponseDto, error)
-	CreateNote(noteInput dto.NoteInputDto, accessToken string) (dto.NoteResponseDto, error)
+	GetNotes(ctx context.Context, userID uuid.UUID, notesQuery dto.NotesQueryDto) (dto.NotesPageDto, error)
+	GetNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) (dto.NoteResponseDto, error)
+	SearchNotes(ctx context.Context, userID uuid.UUID, searchQuery dto.NotesSearchQueryDto) ([]dto.NoteSearchResultDto, error)
+	CreateNote(ctx context.Context, userID uuid.UUID, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error)
+	UpdateNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, noteInput dto.NoteInputDto) (dto.NoteResponseDto, error)
+	PatchNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, notePatch dto.NotePatchDto) (dto.NoteResponseDto, error)
+	MoveNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32, noteMove dto.NoteMoveDto) (dto.NoteResponseDto, error)
+	DeleteNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, versions []int32) error
+	GetNoteRevisions(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) ([]dto.NoteRevisionResponseDto, error)
+	GetNoteRevision(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, revision int32) (dto.NoteRevisionResponseDto, error)
+	DiffNoteRevisions(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, diffQuery dto.NoteRevisionsDiffQueryDto) (dto.NoteRevisionsDiffDto, error)
+	RestoreNoteRevision(ctx context.Context, userID uuid.UUID, noteID uuid.UUID, revision int32, versions []int32) (dto.NoteResponseDto, error)
+	GetTrash(ctx context.Context, userID uuid.UUID) ([]dto.NoteResponseDto, error)
+	RestoreNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) (dto.NoteResponseDto, error)
+	EmptyTrash(ctx context.Context, userID uuid.UUID) error
+}
+
+type Tags interface {
+	GetTags(ctx context.Context, userID uuid.UUID) ([]dto.TagResponseDto, error)
+	UpdateTag(ctx context.Context, userID uuid.UUID, tagID uuid.UUID, tagInput dto.TagInputDto) (dto.TagResponseDto, error)
+	DeleteTag(ctx context.Context, userID uuid.UUID, tagID uuid.UUID) error
+}
+
+type Notebooks interface {
+	GetNotebooks(ctx context.Context, userID uuid.UUID) ([]dto.NotebookResponseDto, error)
+	GetNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID) (dto.NotebookResponseDto, error)
+	CreateNotebook(ctx context.Context, userID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error)
+	UpdateNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID, notebookInput dto.NotebookInputDto) (dto.NotebookResponseDto, error)
+	DeleteNotebook(ctx context.Context, userID uuid.UUID, notebookID uuid.UUID, mode string) error
 }
 
 type Services struct {
-	Users Users
-	Notes Notes
+	Users     Users
+	Notes     Notes
+	Tags      Tags
+	Notebooks Notebooks
 }
 
 type Deps struct {
+	DB           *sql.DB
 	Repo         *database.Queries
 	Hasher       hash.Hasher
+	TokenHasher  hash.Hasher
+	SecretCipher crypt.Cipher
 	Speller      spell.Speller
 	TokenManager auth.TokenManager
+	Mailer       mail.Mailer
+	LoginLimiter *lockout.Limiter
+	IPLimiter    *lockout.Limiter
+	TOTPIssuer   string
+
+	LoginPolicy    policy.LoginPolicy
+	PasswordPolicy policy.PasswordPolicy
+
+	RefreshTokenTTL  time.Duration
+	PasswordResetTTL time.Duration
+	EmailVerifyTTL   time.Duration
 }
 
 func NewServices(deps Deps) *Services {
-	usersService := NewUsersService(deps.Repo, deps.Hasher, deps.TokenManager)
-	notesService := NewNotesService(deps.Repo, deps.Speller, deps.TokenManager)
+	usersService := NewUsersService(deps.DB, deps.Repo, deps.Hasher, deps.TokenHasher, deps.SecretCipher, deps.TokenManager, deps.Mailer, deps.LoginLimiter, deps.IPLimiter, deps.TOTPIssuer, deps.LoginPolicy, deps.PasswordPolicy, deps.RefreshTokenTTL, deps.PasswordResetTTL, deps.EmailVerifyTTL)
+	notesService := NewNotesService(deps.DB, deps.Repo, deps.Speller)
+	tagsService := NewTagsService(deps.DB, deps.Repo)
+	notebooksService := NewNotebooksService(deps.DB, deps.Repo)
 
 	return &Services{
-		Users: usersService,
-		Notes: notesService,
+		Users:     usersService,
+		Notes:     notesService,
+		Tags:      tagsService,
+		Notebooks: notebooksService,
 	}
 }
+
+// isForeignKeyViolation reports whether err is caused by a foreign key
+// constraint.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
+}
+
+// inTx runs fn against a transaction-bound copy of repo and commits only if fn
+// succeeds.
+func inTx(ctx context.Context, db *sql.DB, repo *database.Queries, fn func(*database.Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(repo.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
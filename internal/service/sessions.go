@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"time"
+)
+
+// GetSessions returns the active sessions of the user, the one the request is
+// made from is marked as current.
+func (s *UsersService) GetSessions(ctx context.Context, userID uuid.UUID, currentSessionID uuid.UUID) ([]dto.SessionResponseDto, error) {
+	sessions, err := s.Repo.ListSessions(ctx, database.ListSessionsParams{UserID: userID, ActiveSince: s.sessionsActiveSince()})
+	if err != nil {
+		return nil, domain.ErrGettingSessions.Wrap(err)
+	}
+
+	dtos := make([]dto.SessionResponseDto, len(sessions))
+	for i, session := range sessions {
+		dtos[i] = dto.SessionResponseDto{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.Ip,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.ID == currentSessionID,
+		}
+	}
+
+	return dtos, nil
+}
+
+// DeleteSession revokes a session, neither its refresh token nor its access
+// token can be used anymore.
+func (s *UsersService) DeleteSession(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	accessTokenID, err := s.Repo.DeleteSession(ctx, database.DeleteSessionParams{ID: sessionID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrSessionNotFound
+		}
+		return domain.ErrDeletingSession.Wrap(err)
+	}
+
+	return s.revokeAccessTokens(ctx, accessTokenID)
+}
+
+// revokeReusedSession handles a refresh token of the session that has already
+// been rotated. It's signed by us, so it has been issued to the session before
+// and someone, either the owner or a thief, holds a copy of it. There's no
+// telling which of them presents the current token, so the session is revoked
+// and both have to log in again.
+func (s *UsersService) revokeReusedSession(ctx context.Context, session database.Session, client domain.Client) error {
+	var accessTokenID string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		var err error
+		accessTokenID, err = repo.DeleteSession(ctx, database.DeleteSessionParams{ID: session.ID, UserID: session.UserID})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return recordSecurityEvent(ctx, repo, SecurityEventRefreshTokenReuse, session.UserID, session.ID, client)
+	})
+	if err != nil {
+		return domain.ErrRevokingSession.Wrap(err)
+	}
+
+	if err = s.revokeAccessTokens(ctx, accessTokenID); err != nil {
+		return err
+	}
+
+	return domain.ErrRefreshTokenReused
+}
+
+// newSession starts a session for the client and issues its tokens. Sessions
+// of the user whose refresh tokens have expired are deleted on the way.
+func (s *UsersService) newSession(ctx context.Context, userID uuid.UUID, client domain.Client) (dto.UserResponseDto, string, error) {
+	err := s.Repo.DeleteExpiredSessions(ctx, database.DeleteExpiredSessionsParams{UserID: userID, ActiveSince: s.sessionsActiveSince()})
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingSession.Wrap(err)
+	}
+
+	sessionID := uuid.New()
+
+	refreshToken, err := s.TokenManager.NewRefreshToken(userID, sessionID)
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingRefreshToken.Wrap(err)
+	}
+
+	hashedRefreshToken, err := s.TokenManager.HashRefreshToken(refreshToken)
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrHashingRefreshToken.Wrap(err)
+	}
+
+	accessToken, accessTokenID, err := s.TokenManager.NewAccessToken(userID, sessionID)
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingAccessToken.Wrap(err)
+	}
+
+	_, err = s.Repo.CreateSession(ctx, database.CreateSessionParams{
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshTokenHash: hashedRefreshToken,
+		AccessTokenID:    accessTokenID,
+		UserAgent:        client.UserAgent,
+		Ip:               client.IP,
+	})
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingSession.Wrap(err)
+	}
+
+	return dto.UserResponseDto{ID: userID, AccessToken: accessToken}, refreshToken, nil
+}
+
+// sessionsActiveSince returns the time before which sessions were last used
+// with a refresh token that has expired by now.
+func (s *UsersService) sessionsActiveSince() time.Time {
+	return time.Now().Add(-s.RefreshTokenTTL)
+}
+
+// revokeAccessTokens revokes the access tokens with the ids. Sessions created
+// before access tokens got ids have an empty id, it's skipped.
+func (s *UsersService) revokeAccessTokens(ctx context.Context, accessTokenIDs ...string) error {
+	for _, accessTokenID := range accessTokenIDs {
+		if accessTokenID == "" {
+			continue
+		}
+
+		if err := s.TokenManager.RevokeAccessToken(ctx, accessTokenID); err != nil {
+			return domain.ErrRevokingAccessToken.Wrap(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+)
+
+type TagsService struct {
+	DB   *sql.DB
+	Repo *database.Queries
+}
+
+func NewTagsService(db *sql.DB, repo *database.Queries) *TagsService {
+	return &TagsService{
+		DB:   db,
+		Repo: repo,
+	}
+}
+
+func (s *TagsService) GetTags(ctx context.Context, userID uuid.UUID) ([]dto.TagResponseDto, error) {
+	tags, err := s.Repo.ListTags(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrGettingTags.Wrap(err)
+	}
+
+	dtos := make([]dto.TagResponseDto, len(tags))
+	for i, tag := range tags {
+		dtos[i] = dto.TagResponseDto{ID: tag.ID, Name: tag.Name, NotesCount: tag.NotesCount}
+	}
+
+	return dtos, nil
+}
+
+// UpdateTag renames a tag. If the user already has a tag with the new name,
+// the two are merged: notes of the renamed tag move to the existing one and
+// the renamed tag is deleted.
+func (s *TagsService) UpdateTag(ctx context.Context, userID uuid.UUID, tagID uuid.UUID, tagInput dto.TagInputDto) (dto.TagResponseDto, error) {
+	name := normalizeTagName(tagInput.Name)
+	if name == "" {
+		return dto.TagResponseDto{}, domain.ErrInvalidTagInput
+	}
+
+	var result database.Tag
+	var notesCount int64
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		tag, err := repo.GetTag(ctx, database.GetTagParams{ID: tagID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		result = tag
+
+		if tag.Name != name {
+			// Renaming changes the tags of the notes, so their versions must
+			// change too for conditional requests to notice it.
+			if err = repo.TouchTagNotes(ctx, database.TouchTagNotesParams{TagID: tag.ID, UserID: userID}); err != nil {
+				return err
+			}
+
+			target, err := repo.GetTagByName(ctx, database.GetTagByNameParams{UserID: userID, Name: name})
+			switch {
+			case err == nil:
+				if err = repo.MergeTagNotes(ctx, database.MergeTagNotesParams{TargetID: target.ID, SourceID: tag.ID}); err != nil {
+					return err
+				}
+				if _, err = repo.DeleteTag(ctx, database.DeleteTagParams{ID: tag.ID, UserID: userID}); err != nil {
+					return err
+				}
+				result = target
+			case errors.Is(err, sql.ErrNoRows):
+				result, err = repo.RenameTag(ctx, database.RenameTagParams{ID: tag.ID, UserID: userID, Name: name})
+				if err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+
+		notesCount, err = repo.CountTagNotes(ctx, result.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.TagResponseDto{}, domain.ErrTagNotFound
+		}
+		return dto.TagResponseDto{}, domain.ErrUpdatingTag.Wrap(err)
+	}
+
+	return dto.TagResponseDto{ID: result.ID, Name: result.Name, NotesCount: notesCount}, nil
+}
+
+func (s *TagsService) DeleteTag(ctx context.Context, userID uuid.UUID, tagID uuid.UUID) error {
+	var deleted int64
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		err := repo.TouchTagNotes(ctx, database.TouchTagNotesParams{TagID: tagID, UserID: userID})
+		if err != nil {
+			return err
+		}
+
+		deleted, err = repo.DeleteTag(ctx, database.DeleteTagParams{ID: tagID, UserID: userID})
+		return err
+	})
+	if err != nil {
+		return domain.ErrDeletingTag.Wrap(err)
+	}
+
+	if deleted == 0 {
+		return domain.ErrTagNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	excerptLength = 200
+
+	headlineStartSel = "<mark>"
+	headlineStopSel  = "</mark>"
+)
+
+// newExcerpt cuts content down to excerptLength runes, preferring to break on
+// whitespace so words aren't split in half.
+func newExcerpt(content string) string {
+	if utf8.RuneCountInString(content) <= excerptLength {
+		return content
+	}
+
+	runes := []rune(content)[:excerptLength]
+	excerpt := string(runes)
+	if i := strings.LastIndexAny(excerpt, " \t\n"); i > 0 {
+		excerpt = excerpt[:i]
+	}
+
+	return strings.TrimRight(excerpt, " \t\n.,;:") + "…"
+}
+
+// escapeHeadline HTML-escapes a ts_headline result while keeping the <mark>
+// tags Postgres placed around matched words, so snippets are safe to render.
+func escapeHeadline(headline string) string {
+	escaped := html.EscapeString(headline)
+	escaped = strings.ReplaceAll(escaped, html.EscapeString(headlineStartSel), headlineStartSel)
+	return strings.ReplaceAll(escaped, html.EscapeString(headlineStopSel), headlineStopSel)
+}
+
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normalizeTags lowercases and deduplicates tag names and returns them sorted.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		name := normalizeTagName(tag)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"log"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"time"
+)
+
+const trashPurgeInterval = time.Hour
+
+func (s *NotesService) GetTrash(ctx context.Context, userID uuid.UUID) ([]dto.NoteResponseDto, error) {
+	notes, err := s.Repo.ListDeletedNotes(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrGettingTrash.Wrap(err)
+	}
+
+	notesTags, err := s.getNotesTags(ctx, notes)
+	if err != nil {
+		return nil, domain.ErrGettingNoteTags.Wrap(err)
+	}
+
+	return s.newNotesResponseDto(notes, notesTags, false), nil
+}
+
+// RestoreNote moves a note from the trash back to the notes.
+func (s *NotesService) RestoreNote(ctx context.Context, userID uuid.UUID, noteID uuid.UUID) (dto.NoteResponseDto, error) {
+	note, err := s.Repo.RestoreDeletedNote(ctx, database.RestoreDeletedNoteParams{ID: noteID, UserID: userID})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NoteResponseDto{}, domain.ErrDeletedNoteNotFound
+		}
+		return dto.NoteResponseDto{}, domain.ErrRestoringNote.Wrap(err)
+	}
+
+	tags, err := s.Repo.GetNoteTags(ctx, note.ID)
+	if err != nil {
+		return dto.NoteResponseDto{}, domain.ErrGettingNoteTags.Wrap(err)
+	}
+
+	return s.newNoteResponseDto(note, tags), nil
+}
+
+// EmptyTrash permanently deletes all the notes in the trash of the user.
+func (s *NotesService) EmptyTrash(ctx context.Context, userID uuid.UUID) error {
+	if _, err := s.Repo.EmptyTrash(ctx, userID); err != nil {
+		return domain.ErrEmptyingTrash.Wrap(err)
+	}
+
+	return nil
+}
+
+// TrashPurger permanently deletes notes that have been in the trash for
+// longer than the retention period.
+type TrashPurger struct {
+	Repo      *database.Queries
+	Retention time.Duration
+}
+
+func NewTrashPurger(repo *database.Queries, retention time.Duration) *TrashPurger {
+	return &TrashPurger{
+		Repo:      repo,
+		Retention: retention,
+	}
+}
+
+// Run purges the trash right away and then every trashPurgeInterval until ctx
+// is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.Repo.PurgeDeletedNotes(ctx, time.Now().Add(-p.Retention))
+	if err != nil {
+		log.Println(domain.ErrPurgingTrash.Wrap(err))
+		return
+	}
+
+	if purged != 0 {
+		log.Printf("purged %d notes from trash\n", purged)
+	}
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"notes-service-go/internal/delivery/dto"
+	"notes-service-go/internal/domain"
+	"notes-service-go/pkg/totp"
+	"strings"
+	"time"
+)
+
+const (
+	recoveryCodesCount = 10
+	// recoveryCodeBytes of randomness are encoded as 16 base32 characters.
+	// Recovery codes are hashed with a fast keyed hash, so they're made long
+	// enough not to be brute-forced even with the key.
+	recoveryCodeBytes = 10
+
+	loginChallengeTTL         = 5 * time.Minute
+	maxLoginChallengeAttempts = 5
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// SetupTwoFactor generates a new TOTP secret for the user. It isn't used
+// until EnableTwoFactor confirms that the user's authenticator app produces
+// valid codes for it.
+func (s *UsersService) SetupTwoFactor(ctx context.Context, userID uuid.UUID) (dto.TwoFactorSetupDto, error) {
+	user, err := s.Repo.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.TwoFactorSetupDto{}, domain.ErrUserNotFound
+		}
+		return dto.TwoFactorSetupDto{}, domain.ErrGettingUser.Wrap(err)
+	}
+
+	if user.TotpEnabledAt.Valid {
+		return dto.TwoFactorSetupDto{}, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return dto.TwoFactorSetupDto{}, domain.ErrSettingUpTwoFactor.Wrap(err)
+	}
+
+	encryptedSecret, err := s.SecretCipher.Encrypt(secret)
+	if err != nil {
+		return dto.TwoFactorSetupDto{}, domain.ErrSettingUpTwoFactor.Wrap(err)
+	}
+
+	err = s.Repo.SetUserTOTPSecret(ctx, database.SetUserTOTPSecretParams{ID: userID, TotpSecret: sql.NullString{String: encryptedSecret, Valid: true}})
+	if err != nil {
+		return dto.TwoFactorSetupDto{}, domain.ErrSettingUpTwoFactor.Wrap(err)
+	}
+
+	return dto.TwoFactorSetupDto{
+		Secret:     secret,
+		OtpauthURI: totp.URI(s.TOTPIssuer, user.Login, secret),
+	}, nil
+}
+
+// EnableTwoFactor enables two-factor authentication once the code confirms
+// the secret from SetupTwoFactor. It returns the recovery codes, they're
+// only stored hashed and can't be shown again.
+func (s *UsersService) EnableTwoFactor(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, twoFactorCode dto.TwoFactorCodeDto, client domain.Client) (dto.RecoveryCodesDto, error) {
+	recoveryCodes, hashedRecoveryCodes, err := s.newRecoveryCodes()
+	if err != nil {
+		return dto.RecoveryCodesDto{}, domain.ErrEnablingTwoFactor.Wrap(err)
+	}
+
+	err = inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		userTOTP, err := repo.GetUserTOTPForUpdate(ctx, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrUserNotFound
+			}
+			return err
+		}
+
+		if userTOTP.TotpEnabledAt.Valid {
+			return domain.ErrTwoFactorAlreadyEnabled
+		}
+		if !userTOTP.TotpSecret.Valid {
+			return domain.ErrTwoFactorNotSetUp
+		}
+
+		step, ok, err := s.validateTOTP(userTOTP, twoFactorCode.Code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrWrongTwoFactorCode
+		}
+
+		if err = repo.EnableUserTOTP(ctx, database.EnableUserTOTPParams{ID: userID, TotpLastStep: step}); err != nil {
+			return err
+		}
+
+		if err = repo.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+
+		err = repo.CreateRecoveryCodes(ctx, database.CreateRecoveryCodesParams{UserID: userID, CodeHashes: hashedRecoveryCodes})
+		if err != nil {
+			return err
+		}
+
+		return recordSecurityEvent(ctx, repo, SecurityEventTwoFactorEnabled, userID, sessionID, client)
+	})
+	if err != nil {
+		return dto.RecoveryCodesDto{}, domain.ErrEnablingTwoFactor.Wrap(err)
+	}
+
+	return dto.RecoveryCodesDto{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableTwoFactor disables two-factor authentication once both the password
+// and a code are confirmed. Wrong codes are counted against the login like
+// wrong passwords, and the lockout is checked before either is tested.
+func (s *UsersService) DisableTwoFactor(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, twoFactorDisable dto.TwoFactorDisableDto, client domain.Client) error {
+	loginKey, err := s.verifyPassword(ctx, userID, twoFactorDisable.Password)
+	if err != nil {
+		return err
+	}
+
+	err = inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		ok, err := s.checkTwoFactorCode(ctx, repo, userID, twoFactorDisable.Code, client)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrWrongTwoFactorCode
+		}
+
+		if err = repo.DisableUserTOTP(ctx, userID); err != nil {
+			return err
+		}
+
+		if err = repo.DeleteRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+
+		return recordSecurityEvent(ctx, repo, SecurityEventTwoFactorDisabled, userID, sessionID, client)
+	})
+	if errors.Is(err, domain.ErrWrongTwoFactorCode) {
+		if err = s.LoginLimiter.Fail(ctx, loginKey); err != nil {
+			return domain.ErrCheckingLoginAttempts.Wrap(err)
+		}
+		return domain.ErrWrongTwoFactorCode
+	}
+	if err != nil {
+		return domain.ErrDisablingTwoFactor.Wrap(err)
+	}
+
+	if err = s.LoginLimiter.Reset(ctx, loginKey); err != nil {
+		return domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	return nil
+}
+
+// CompleteLogin finishes the login of a user with two-factor authentication.
+// A challenge is rejected after maxLoginChallengeAttempts wrong codes, then
+// the user has to log in with the password again. Wrong codes are counted
+// against the login and the IP address like wrong passwords, so new challenges
+// don't give more attempts, and the failures of the login are only reset once
+// the code is confirmed.
+func (s *UsersService) CompleteLogin(ctx context.Context, loginChallenge dto.LoginChallengeDto, client domain.Client) (dto.UserResponseDto, string, error) {
+	hashedToken, err := s.TokenHasher.Hash(loginChallenge.ChallengeToken)
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCompletingLogin.Wrap(err)
+	}
+
+	var (
+		userID   uuid.UUID
+		loginKey string
+		codeErr  error
+	)
+	ipKey := "ip:" + client.IP
+
+	// A wrong code is counted in the transaction, so it has to be committed
+	// and the error is returned after it.
+	err = inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		challenge, err := repo.GetLoginChallengeForUpdate(ctx, hashedToken)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return domain.ErrInvalidLoginChallenge
+			}
+			return err
+		}
+
+		user, err := repo.GetUser(ctx, challenge.UserID)
+		if err != nil {
+			return err
+		}
+
+		loginKey = loginLockoutKey(user.Login)
+		if err = s.checkLoginLockout(ctx, loginKey, ipKey); err != nil {
+			return err
+		}
+
+		ok, err := s.checkTwoFactorCode(ctx, repo, challenge.UserID, loginChallenge.Code, client)
+		if err != nil {
+			// Two-factor authentication has been disabled since the login.
+			if errors.Is(err, domain.ErrTwoFactorNotEnabled) {
+				return domain.ErrInvalidLoginChallenge
+			}
+			return err
+		}
+
+		if !ok {
+			codeErr = domain.ErrWrongTwoFactorCode
+
+			attempts, err := repo.IncrementLoginChallengeAttempts(ctx, hashedToken)
+			if err != nil {
+				return err
+			}
+			if attempts >= maxLoginChallengeAttempts {
+				return repo.DeleteLoginChallenge(ctx, hashedToken)
+			}
+			return nil
+		}
+
+		userID = challenge.UserID
+		return repo.DeleteLoginChallenge(ctx, hashedToken)
+	})
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCompletingLogin.Wrap(err)
+	}
+	if codeErr != nil {
+		if err = s.countLoginFailure(ctx, loginKey, ipKey); err != nil {
+			return dto.UserResponseDto{}, "", err
+		}
+		return dto.UserResponseDto{}, "", codeErr
+	}
+
+	if err = s.LoginLimiter.Reset(ctx, loginKey); err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	return s.newSession(ctx, userID, client)
+}
+
+// newLoginChallenge starts the second step of the login, the user gets no
+// tokens until CompleteLogin.
+func (s *UsersService) newLoginChallenge(ctx context.Context, userID uuid.UUID) (dto.UserResponseDto, string, error) {
+	if err := s.Repo.DeleteExpiredLoginChallenges(ctx, userID); err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingLoginChallenge.Wrap(err)
+	}
+
+	token, hashedToken, err := s.newRandomToken()
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingLoginChallenge.Wrap(err)
+	}
+
+	err = s.Repo.CreateLoginChallenge(ctx, database.CreateLoginChallengeParams{
+		TokenHash: hashedToken,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(loginChallengeTTL),
+	})
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCreatingLoginChallenge.Wrap(err)
+	}
+
+	return dto.UserResponseDto{ID: userID, TwoFactorRequired: true, ChallengeToken: token}, "", nil
+}
+
+// checkTwoFactorCode checks a TOTP code or a recovery code of the user with
+// two-factor authentication enabled. A used recovery code is deleted, a used
+// TOTP code is remembered, so that neither can be used again.
+func (s *UsersService) checkTwoFactorCode(ctx context.Context, repo *database.Queries, userID uuid.UUID, code string, client domain.Client) (bool, error) {
+	userTOTP, err := repo.GetUserTOTPForUpdate(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, domain.ErrUserNotFound
+		}
+		return false, err
+	}
+
+	if !userTOTP.TotpEnabledAt.Valid {
+		return false, domain.ErrTwoFactorNotEnabled
+	}
+
+	if !isTOTPCode(code) {
+		return s.useRecoveryCode(ctx, repo, userID, code, client)
+	}
+
+	step, ok, err := s.validateTOTP(userTOTP, code)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err = repo.SetUserTOTPLastStep(ctx, database.SetUserTOTPLastStepParams{ID: userID, TotpLastStep: step}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// validateTOTP checks the code against the user's secret. Codes of the time
+// steps up to the last used one are rejected.
+func (s *UsersService) validateTOTP(userTOTP database.GetUserTOTPForUpdateRow, code string) (int64, bool, error) {
+	secret, err := s.SecretCipher.Decrypt(userTOTP.TotpSecret.String)
+	if err != nil {
+		return 0, false, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok || step <= userTOTP.TotpLastStep {
+		return 0, false, nil
+	}
+
+	return step, true, nil
+}
+
+// useRecoveryCode deletes the recovery code of the user if there's one.
+// Recovery codes are random, so unlike passwords they're hashed with
+// TokenHasher and looked up by the hash.
+func (s *UsersService) useRecoveryCode(ctx context.Context, repo *database.Queries, userID uuid.UUID, code string, client domain.Client) (bool, error) {
+	hashedCode, err := s.TokenHasher.Hash(normalizeRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := repo.DeleteRecoveryCode(ctx, database.DeleteRecoveryCodeParams{UserID: userID, CodeHash: hashedCode})
+	if err != nil || deleted == 0 {
+		return false, err
+	}
+
+	return true, recordSecurityEvent(ctx, repo, SecurityEventRecoveryCodeUsed, userID, uuid.Nil, client)
+}
+
+// newRecoveryCodes returns recovery codes formatted for the user, like
+// abcdefgh-ijklmnop, and their hashes.
+func (s *UsersService) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashedCodes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+
+		hashedCode, err := s.TokenHasher.Hash(code)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes[i] = code[:len(code)/2] + "-" + code[len(code)/2:]
+		hashedCodes[i] = hashedCode
+	}
+
+	return codes, hashedCodes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
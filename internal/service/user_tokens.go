@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/google/uuid"
+	"notes-service-go/internal/database"
+	"time"
+)
+
+// Purposes of single-use user tokens.
+const (
+	UserTokenPasswordReset = "password_reset"
+	UserTokenEmailVerify   = "email_verify"
+)
+
+const randomTokenBytes = 32
+
+// newUserToken issues a single-use token for the purpose and invalidates the
+// tokens the user has been issued for it before. Only the hash of the token
+// is stored. email is the address the token is sent to.
+func (s *UsersService) newUserToken(ctx context.Context, repo *database.Queries, userID uuid.UUID, purpose string, email string, ttl time.Duration) (string, error) {
+	token, hashedToken, err := s.newRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err = repo.DeleteUserTokens(ctx, database.DeleteUserTokensParams{UserID: userID, Purpose: purpose}); err != nil {
+		return "", err
+	}
+
+	err = repo.CreateUserToken(ctx, database.CreateUserTokenParams{
+		TokenHash: hashedToken,
+		UserID:    userID,
+		Purpose:   purpose,
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeUserToken deletes the token, so that it can only be used once, and
+// returns the user and the email it has been issued for. It returns
+// sql.ErrNoRows when the token is unknown, expired or already used.
+func (s *UsersService) consumeUserToken(ctx context.Context, repo *database.Queries, token string, purpose string) (database.ConsumeUserTokenRow, error) {
+	hashedToken, err := s.TokenHasher.Hash(token)
+	if err != nil {
+		return database.ConsumeUserTokenRow{}, err
+	}
+
+	return repo.ConsumeUserToken(ctx, database.ConsumeUserTokenParams{TokenHash: hashedToken, Purpose: purpose})
+}
+
+// newRandomToken returns a random token and its hash to store.
+func (s *UsersService) newRandomToken() (string, string, error) {
+	b := make([]byte, randomTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	hashedToken, err := s.TokenHasher.Hash(token)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, hashedToken, nil
+}
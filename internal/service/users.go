@@ -4,156 +4,486 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/google/uuid"
+	"log"
 	"notes-service-go/internal/database"
 	"notes-service-go/internal/delivery/dto"
 	"notes-service-go/internal/domain"
 	"notes-service-go/pkg/auth"
+	"notes-service-go/pkg/crypt"
 	"notes-service-go/pkg/hash"
+	"notes-service-go/pkg/lockout"
+	"notes-service-go/pkg/mail"
+	"notes-service-go/pkg/policy"
+	"strings"
+	"sync"
 	"time"
 )
 
+// dummyPassword is hashed once to be compared with the passwords of logins
+// that don't exist, see Login.
+const dummyPassword = "dummy password"
+
+// UsersService manages users and their sessions. Hasher hashes passwords,
+// TokenHasher hashes the single-use tokens mailed to users, login challenges
+// and recovery codes, SecretCipher encrypts TOTP secrets. LoginLimiter and
+// IPLimiter lock out logins and IP addresses with too many failed login
+// attempts. LoginPolicy and PasswordPolicy restrict the logins and passwords
+// users may choose.
 type UsersService struct {
+	DB           *sql.DB
 	Repo         *database.Queries
 	Hasher       hash.Hasher
+	TokenHasher  hash.Hasher
+	SecretCipher crypt.Cipher
 	TokenManager auth.TokenManager
+	Mailer       mail.Mailer
+	LoginLimiter *lockout.Limiter
+	IPLimiter    *lockout.Limiter
+	TOTPIssuer   string
+
+	LoginPolicy    policy.LoginPolicy
+	PasswordPolicy policy.PasswordPolicy
 
-	AccessTokenTTL time.Duration
+	RefreshTokenTTL  time.Duration
+	PasswordResetTTL time.Duration
+	EmailVerifyTTL   time.Duration
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
-func NewUsersService(repo *database.Queries, hasher hash.Hasher, tokenManager auth.TokenManager) *UsersService {
+func NewUsersService(db *sql.DB, repo *database.Queries, hasher hash.Hasher, tokenHasher hash.Hasher, secretCipher crypt.Cipher, tokenManager auth.TokenManager, mailer mail.Mailer, loginLimiter *lockout.Limiter, ipLimiter *lockout.Limiter, totpIssuer string, loginPolicy policy.LoginPolicy, passwordPolicy policy.PasswordPolicy, refreshTokenTTL time.Duration, passwordResetTTL time.Duration, emailVerifyTTL time.Duration) *UsersService {
 	return &UsersService{
-		Repo:         repo,
-		Hasher:       hasher,
-		TokenManager: tokenManager,
+		DB:               db,
+		Repo:             repo,
+		Hasher:           hasher,
+		TokenHasher:      tokenHasher,
+		SecretCipher:     secretCipher,
+		TokenManager:     tokenManager,
+		Mailer:           mailer,
+		LoginLimiter:     loginLimiter,
+		IPLimiter:        ipLimiter,
+		TOTPIssuer:       totpIssuer,
+		LoginPolicy:      loginPolicy,
+		PasswordPolicy:   passwordPolicy,
+		RefreshTokenTTL:  refreshTokenTTL,
+		PasswordResetTTL: passwordResetTTL,
+		EmailVerifyTTL:   emailVerifyTTL,
 	}
 }
 
-func (s *UsersService) CreateUser(userCredentials dto.UserCredentialsDto) (dto.UserResponseDto, string, error) {
-	exist, err := s.Repo.CheckUserExist(context.Background(), userCredentials.Login)
+// CreateUser registers a user and starts a session. The login is stored in its
+// normalized form, see policy.NormalizeLogin, and logins are unique ignoring
+// case.
+func (s *UsersService) CreateUser(ctx context.Context, userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error) {
+	login := policy.NormalizeLogin(userCredentials.Login)
+
+	if err := s.checkCredentials(login, userCredentials.Password); err != nil {
+		return dto.UserResponseDto{}, "", err
+	}
+
+	exist, err := s.Repo.CheckUserExist(ctx, login)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCheckingUserExist+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrCheckingUserExist.Wrap(err)
 	}
 	if exist {
-		return dto.UserResponseDto{}, "", errors.New(domain.ErrUserAlreadyExists)
+		return dto.UserResponseDto{}, "", domain.ErrUserAlreadyExists
 	}
 
 	hashedPassword, err := s.Hasher.Hash(userCredentials.Password)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrHashingPassword+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrHashingPassword.Wrap(err)
 	}
 
-	userID, err := s.Repo.CreateUser(context.Background(), database.CreateUserParams{Login: userCredentials.Login, Password: hashedPassword})
+	userID, err := s.Repo.CreateUser(ctx, database.CreateUserParams{Login: login, Password: hashedPassword})
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingUser+": %s\n", err)
+		// The login has been taken by a concurrent registration.
+		if isUniqueViolation(err) {
+			return dto.UserResponseDto{}, "", domain.ErrUserAlreadyExists
+		}
+		return dto.UserResponseDto{}, "", domain.ErrCreatingUser.Wrap(err)
 	}
 
-	refreshToken, err := s.TokenManager.NewRefreshToken(userID)
+	return s.newSession(ctx, userID, client)
+}
+
+// Refresh rotates the refresh token of the session it belongs to and issues a
+// new access token for the session, the previous access token is revoked. A
+// refresh token that has already been rotated revokes the session, see
+// revokeReusedSession.
+func (s *UsersService) Refresh(ctx context.Context, refreshToken string, client domain.Client) (dto.UserResponseDto, string, error) {
+	claims, err := s.TokenManager.ParseRefreshToken(refreshToken)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingRefreshToken+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrInvalidRefreshToken.Wrap(err)
 	}
 
-	accessToken, err := s.TokenManager.NewAccessToken(userID)
+	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingAccessToken+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrInvalidRefreshToken.Wrap(err)
 	}
 
-	if err = s.Repo.SaveRefreshToken(context.Background(), database.SaveRefreshTokenParams{ID: userID, RefreshToken: refreshToken}); err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrSavingRefreshToken+": %s\n", err)
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrInvalidRefreshToken.Wrap(err)
 	}
 
-	return dto.UserResponseDto{ID: userID, AccessToken: accessToken}, refreshToken, nil
-}
-
-func (s *UsersService) Refresh(refreshToken string) (dto.UserResponseDto, string, error) {
-	userIDStr, err := s.TokenManager.ParseRefreshToken(refreshToken)
+	session, err := s.Repo.GetSession(ctx, database.GetSessionParams{ID: sessionID, UserID: userID})
 	if err != nil {
-		if err.Error() == domain.ErrRefreshTokenUndefined {
-			return dto.UserResponseDto{}, "", err
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.UserResponseDto{}, "", domain.ErrInvalidRefreshToken.Wrap(err)
 		}
-		return dto.UserResponseDto{}, "", errors.New(domain.ErrInvalidRefreshToken)
+		return dto.UserResponseDto{}, "", domain.ErrGettingSession.Wrap(err)
 	}
 
-	userID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrParsingID+" :%s\n", err)
+	if !s.TokenManager.IsValidRefreshToken(session.RefreshTokenHash, refreshToken) {
+		return dto.UserResponseDto{}, "", s.revokeReusedSession(ctx, session, client)
 	}
 
-	storedRefreshToken, err := s.Repo.GetRefreshTokenById(context.Background(), userID)
+	refreshToken, err = s.TokenManager.NewRefreshToken(userID, sessionID)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrGettingRefreshTokenFromDB+" :%s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrCreatingRefreshToken.Wrap(err)
 	}
 
-	if storedRefreshToken != refreshToken {
-		return dto.UserResponseDto{}, "", errors.New(domain.ErrInvalidRefreshToken)
+	hashedRefreshToken, err := s.TokenManager.HashRefreshToken(refreshToken)
+	if err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrHashingRefreshToken.Wrap(err)
 	}
 
-	refreshToken, err = s.TokenManager.NewRefreshToken(userID)
+	accessToken, accessTokenID, err := s.TokenManager.NewAccessToken(userID, sessionID)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingRefreshToken+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrCreatingAccessToken.Wrap(err)
 	}
 
-	accessToken, err := s.TokenManager.NewAccessToken(userID)
+	rotated, err := s.Repo.RotateSession(ctx, database.RotateSessionParams{
+		NewRefreshTokenHash: hashedRefreshToken,
+		AccessTokenID:       accessTokenID,
+		UserAgent:           client.UserAgent,
+		Ip:                  client.IP,
+		ID:                  sessionID,
+		RefreshTokenHash:    session.RefreshTokenHash,
+	})
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingAccessToken+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrRotatingSession.Wrap(err)
 	}
 
-	if err = s.Repo.SaveRefreshToken(context.Background(), database.SaveRefreshTokenParams{ID: userID, RefreshToken: refreshToken}); err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrSavingRefreshToken+": %s\n", err)
+	// The token has been rotated by a concurrent refresh with the same token.
+	if rotated == 0 {
+		return dto.UserResponseDto{}, "", s.revokeReusedSession(ctx, session, client)
+	}
+
+	// The session keeps only the latest access token valid.
+	if err = s.revokeAccessTokens(ctx, session.AccessTokenID); err != nil {
+		return dto.UserResponseDto{}, "", err
 	}
 
 	return dto.UserResponseDto{ID: userID, AccessToken: accessToken}, refreshToken, nil
 }
 
-func (s *UsersService) Login(userCredentials dto.UserCredentialsDto) (dto.UserResponseDto, string, error) {
-	user, err := s.Repo.GetUserByLogin(context.Background(), userCredentials.Login)
+// Login checks the credentials and starts a session. For a user with
+// two-factor authentication it only returns a login challenge, see
+// CompleteLogin. Logins are normalized and matched ignoring case, like at
+// registration. Failed attempts are counted per login and per IP address, see
+// checkLoginLockout. Logins that don't exist are counted and answered the
+// same way as existing ones, so that neither the response nor its timing
+// tells whether a login exists.
+func (s *UsersService) Login(ctx context.Context, userCredentials dto.UserCredentialsDto, client domain.Client) (dto.UserResponseDto, string, error) {
+	login := policy.NormalizeLogin(userCredentials.Login)
+	loginKey, ipKey := loginLockoutKey(login), "ip:"+client.IP
+
+	if err := s.checkLoginLockout(ctx, loginKey, ipKey); err != nil {
+		return dto.UserResponseDto{}, "", err
+	}
+
+	user, err := s.Repo.GetUserByLogin(ctx, login)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrUserNotFound+": %s\n", err)
+			s.Hasher.IsValidData(s.dummyPasswordHash(), userCredentials.Password)
+			return dto.UserResponseDto{}, "", s.failLogin(ctx, loginKey, ipKey)
 		}
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrGettingPassword+": %s\n", err)
+		return dto.UserResponseDto{}, "", domain.ErrGettingPassword.Wrap(err)
 	}
 
 	valid := s.Hasher.IsValidData(user.Password, userCredentials.Password)
 	if !valid {
-		return dto.UserResponseDto{}, "", errors.New(domain.ErrWrongPassword)
+		return dto.UserResponseDto{}, "", s.failLogin(ctx, loginKey, ipKey)
+	}
+
+	s.rehashPassword(ctx, user.ID, user.Password, userCredentials.Password)
+
+	// The failures of the login are kept until the second factor is
+	// confirmed too, see CompleteLogin.
+	if user.TotpEnabledAt.Valid {
+		return s.newLoginChallenge(ctx, user.ID)
 	}
 
-	refreshToken, err := s.TokenManager.NewRefreshToken(user.ID)
+	// The failures of the IP address are kept, otherwise logging in to an
+	// own account would let an attacker guess passwords of other accounts
+	// from the same address.
+	if err = s.LoginLimiter.Reset(ctx, loginKey); err != nil {
+		return dto.UserResponseDto{}, "", domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	return s.newSession(ctx, user.ID, client)
+}
+
+// Logout ends the session the access token was issued for and revokes the
+// access token. Other sessions of the user stay active.
+func (s *UsersService) Logout(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	accessTokenID, err := s.Repo.DeleteSession(ctx, database.DeleteSessionParams{ID: sessionID, UserID: userID})
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingRefreshToken+": %s\n", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return domain.ErrLogout.Wrap(err)
 	}
 
-	accessToken, err := s.TokenManager.NewAccessToken(user.ID)
+	return s.revokeAccessTokens(ctx, accessTokenID)
+}
+
+// LogoutAll ends all sessions of the user and revokes their access tokens.
+func (s *UsersService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	accessTokenIDs, err := s.Repo.DeleteUserSessions(ctx, userID)
 	if err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrCreatingAccessToken+": %s\n", err)
+		return domain.ErrLogout.Wrap(err)
 	}
 
-	if err = s.Repo.SaveRefreshToken(context.Background(), database.SaveRefreshTokenParams{ID: user.ID, RefreshToken: refreshToken}); err != nil {
-		return dto.UserResponseDto{}, "", fmt.Errorf(domain.ErrSavingRefreshToken+": %s\n", err)
+	return s.revokeAccessTokens(ctx, accessTokenIDs...)
+}
+
+// checkLoginLockout rejects the login if either the login or the IP address
+// is locked out after too many failed attempts. Even the right password is
+// rejected then, otherwise the lockout wouldn't slow down guessing.
+func (s *UsersService) checkLoginLockout(ctx context.Context, loginKey string, ipKey string) error {
+	loginRetryAfter, err := s.LoginLimiter.RetryAfter(ctx, loginKey)
+	if err != nil {
+		return domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	ipRetryAfter, err := s.IPLimiter.RetryAfter(ctx, ipKey)
+	if err != nil {
+		return domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	if retryAfter := max(loginRetryAfter, ipRetryAfter); retryAfter > 0 {
+		return domain.ErrTooManyLoginAttempts.WithRetryAfter(retryAfter)
 	}
 
-	return dto.UserResponseDto{ID: user.ID, AccessToken: accessToken}, refreshToken, nil
+	return nil
 }
 
-func (s *UsersService) Logout(accessToken string) error {
-	userIDStr, err := s.TokenManager.ParseAccessToken(accessToken)
+// failLogin counts a failed login attempt and returns the error to answer it
+// with.
+func (s *UsersService) failLogin(ctx context.Context, loginKey string, ipKey string) error {
+	if err := s.countLoginFailure(ctx, loginKey, ipKey); err != nil {
+		return err
+	}
+
+	return domain.ErrWrongCredentials
+}
+
+// countLoginFailure counts a failed login attempt against both the login and
+// the IP address.
+func (s *UsersService) countLoginFailure(ctx context.Context, loginKey string, ipKey string) error {
+	if err := s.LoginLimiter.Fail(ctx, loginKey); err != nil {
+		return domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	if err := s.IPLimiter.Fail(ctx, ipKey); err != nil {
+		return domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+
+	return nil
+}
+
+// rehashPassword replaces a password hash made with another algorithm or
+// outdated parameters with a hash made by Hasher. It's only possible right
+// after the password has been checked, while it's known. Errors are only
+// logged, the hash is upgraded at the next login then.
+func (s *UsersService) rehashPassword(ctx context.Context, userID uuid.UUID, hashedPassword string, password string) {
+	rehasher, ok := s.Hasher.(hash.Rehasher)
+	if !ok || !rehasher.NeedsRehash(hashedPassword) {
+		return
+	}
+
+	newHashedPassword, err := s.Hasher.Hash(password)
 	if err != nil {
-		if err.Error() == domain.ErrAccessTokenUndefined {
+		log.Println(domain.ErrRehashingPassword.Wrap(err))
+		return
+	}
+
+	// The hash is only replaced if it's still the checked one, so that a
+	// concurrent password change isn't undone.
+	err = s.Repo.RehashUserPassword(ctx, database.RehashUserPasswordParams{
+		NewPassword: newHashedPassword,
+		ID:          userID,
+		OldPassword: hashedPassword,
+	})
+	if err != nil {
+		log.Println(domain.ErrRehashingPassword.Wrap(err))
+	}
+}
+
+// dummyPasswordHash returns the hash of dummyPassword. Comparing a password
+// with it takes as long as with the hash of a real user.
+func (s *UsersService) dummyPasswordHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = s.Hasher.Hash(dummyPassword)
+	})
+
+	return s.dummyHash
+}
+
+// GetUser returns the profile of the user.
+func (s *UsersService) GetUser(ctx context.Context, userID uuid.UUID) (dto.UserProfileDto, error) {
+	user, err := s.Repo.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.UserProfileDto{}, domain.ErrUserNotFound
+		}
+		return dto.UserProfileDto{}, domain.ErrGettingUser.Wrap(err)
+	}
+
+	return dto.UserProfileDto{
+		ID:            user.ID,
+		Login:         user.Login,
+		Email:         user.Email.String,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+
+		TwoFactorEnabled: user.TotpEnabledAt.Valid,
+	}, nil
+}
+
+// ChangePassword sets a new password once the current one is confirmed. All
+// other sessions of the user and pending password resets are ended, whoever
+// may know the old password is logged out, the session the request is made
+// from stays active. The new password has to meet PasswordPolicy.
+func (s *UsersService) ChangePassword(ctx context.Context, userID uuid.UUID, sessionID uuid.UUID, passwordChange dto.PasswordChangeDto, client domain.Client) error {
+	if err := s.checkPassword(ctx, userID, passwordChange.CurrentPassword); err != nil {
+		return err
+	}
+
+	user, err := s.Repo.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		return domain.ErrGettingUser.Wrap(err)
+	}
+
+	if err = s.checkNewPassword(user.Login, passwordChange.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.Hasher.Hash(passwordChange.NewPassword)
+	if err != nil {
+		return domain.ErrHashingPassword.Wrap(err)
+	}
+
+	var accessTokenIDs []string
+
+	err = inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		err := repo.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{ID: userID, Password: hashedPassword})
+		if err != nil {
+			return err
+		}
+
+		accessTokenIDs, err = repo.DeleteOtherUserSessions(ctx, database.DeleteOtherUserSessionsParams{UserID: userID, ID: sessionID})
+		if err != nil {
+			return err
+		}
+
+		err = repo.DeleteUserTokens(ctx, database.DeleteUserTokensParams{UserID: userID, Purpose: UserTokenPasswordReset})
+		if err != nil {
+			return err
+		}
+
+		return recordSecurityEvent(ctx, repo, SecurityEventPasswordChange, userID, sessionID, client)
+	})
+	if err != nil {
+		return domain.ErrChangingPassword.Wrap(err)
+	}
+
+	return s.revokeAccessTokens(ctx, accessTokenIDs...)
+}
+
+// DeleteUser deletes the user with all of their data once the password is
+// confirmed. Notes, notebooks, tags and security events are deleted with the
+// user by ON DELETE CASCADE, sessions are deleted first to revoke their access
+// tokens.
+func (s *UsersService) DeleteUser(ctx context.Context, userID uuid.UUID, userDelete dto.UserDeleteDto) error {
+	if err := s.checkPassword(ctx, userID, userDelete.Password); err != nil {
+		return err
+	}
+
+	var accessTokenIDs []string
+
+	err := inTx(ctx, s.DB, s.Repo, func(repo *database.Queries) error {
+		var err error
+		accessTokenIDs, err = repo.DeleteUserSessions(ctx, userID)
+		if err != nil {
 			return err
 		}
-		return errors.New(domain.ErrInvalidAccessToken)
+
+		return repo.DeleteUser(ctx, userID)
+	})
+	if err != nil {
+		return domain.ErrDeletingUser.Wrap(err)
 	}
 
-	userID, err := uuid.Parse(userIDStr)
+	return s.revokeAccessTokens(ctx, accessTokenIDs...)
+}
+
+// checkPassword makes sure password is the current password of the user.
+// Wrong passwords are counted against the login like failed logins, otherwise
+// a stolen access token would allow guessing the password without a limit.
+func (s *UsersService) checkPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	loginKey, err := s.verifyPassword(ctx, userID, password)
 	if err != nil {
-		return fmt.Errorf(domain.ErrParsingID+" :%s\n", err)
+		return err
 	}
 
-	if err = s.Repo.Logout(context.Background(), userID); err != nil {
-		return fmt.Errorf(domain.ErrLogout+" :%s\n", err)
+	if err = s.LoginLimiter.Reset(ctx, loginKey); err != nil {
+		return domain.ErrCheckingLoginAttempts.Wrap(err)
 	}
 
 	return nil
 }
+
+// verifyPassword is checkPassword that keeps the failures of the login. It
+// returns the key they're counted under, so that the caller can count wrong
+// second factors under it too and reset it once they're confirmed.
+func (s *UsersService) verifyPassword(ctx context.Context, userID uuid.UUID, password string) (string, error) {
+	user, err := s.Repo.GetUserPassword(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", domain.ErrUserNotFound
+		}
+		return "", domain.ErrGettingPassword.Wrap(err)
+	}
+
+	loginKey := loginLockoutKey(user.Login)
+
+	retryAfter, err := s.LoginLimiter.RetryAfter(ctx, loginKey)
+	if err != nil {
+		return "", domain.ErrCheckingLoginAttempts.Wrap(err)
+	}
+	if retryAfter > 0 {
+		return "", domain.ErrTooManyLoginAttempts.WithRetryAfter(retryAfter)
+	}
+
+	if !s.Hasher.IsValidData(user.Password, password) {
+		if err = s.LoginLimiter.Fail(ctx, loginKey); err != nil {
+			return "", domain.ErrCheckingLoginAttempts.Wrap(err)
+		}
+		return "", domain.ErrWrongPassword
+	}
+
+	return loginKey, nil
+}
+
+// loginLockoutKey is the key failed attempts to guess the password of the
+// login are counted under.
+func loginLockoutKey(login string) string {
+	return "login:" + strings.ToLower(login)
+}
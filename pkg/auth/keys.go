@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"math/big"
+	"os"
+)
+
+const (
+	errReadingKeyFile      = "error reading key file"
+	errDecodingPEM         = "no PEM block found"
+	errUnsupportedPEMBlock = "unsupported PEM block type"
+	errUnsupportedKey      = "unsupported key type, only RSA and Ed25519 keys are supported"
+	errRSAKeyTooShort      = "RSA key must be at least 2048 bits long"
+	errNoKeys              = "at least one key is required"
+	errNoSigningKey        = "the first key must be a private key, it signs new tokens"
+	errDuplicateKeyID      = "duplicate key id"
+	errEmptyKeyID          = "key id is empty"
+	errUnknownKeyID        = "unknown key id"
+	errMissingKeyID        = "token has no kid header"
+	errEmptyLegacySecret   = "legacy secret is empty"
+
+	minRSAKeyBits = 2048
+)
+
+// SigningKey is a key access tokens are signed or verified with. Private is
+// nil for keys that are only kept to verify tokens signed before a rotation.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// LoadSigningKey reads an RSA or Ed25519 key from a PEM file. The file may
+// hold a private key in PKCS#8 or PKCS#1 form or a public key in PKIX form.
+func LoadSigningKey(id string, path string) (SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf(errReadingKeyFile+" %s: %w", path, err)
+	}
+
+	key, err := ParseSigningKey(id, data)
+	if err != nil {
+		return SigningKey{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+// ParseSigningKey parses a PEM encoded RSA or Ed25519 key.
+func ParseSigningKey(id string, data []byte) (SigningKey, error) {
+	if id == "" {
+		return SigningKey{}, errors.New(errEmptyKeyID)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errors.New(errDecodingPEM)
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return SigningKey{}, fmt.Errorf(errUnsupportedPEMBlock+": %s", block.Type)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return SigningKey{}, errors.New(errRSAKeyTooShort)
+		}
+		return SigningKey{ID: id, Method: jwt.SigningMethodRS256, Private: k, Public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return SigningKey{}, errors.New(errRSAKeyTooShort)
+		}
+		return SigningKey{ID: id, Method: jwt.SigningMethodRS256, Public: k}, nil
+	case ed25519.PrivateKey:
+		return SigningKey{ID: id, Method: SigningMethodEdDSA, Private: k, Public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return SigningKey{ID: id, Method: SigningMethodEdDSA, Public: k}, nil
+	default:
+		return SigningKey{}, errors.New(errUnsupportedKey)
+	}
+}
+
+// KeySet holds the keys of access tokens. The first key signs new tokens, the
+// others only verify tokens, so that a retired key keeps accepting the tokens
+// it signed until they expire and a new key can be published before it's
+// used.
+//
+// A set may also hold the shared secret access tokens were signed with before
+// key sets, see NewLegacyKeySet and WithLegacySecret. Such tokens are signed
+// with HS512 and have no kid header.
+type KeySet struct {
+	signing      SigningKey
+	keys         []SigningKey
+	byID         map[string]SigningKey
+	legacySecret []byte
+}
+
+func NewKeySet(keys ...SigningKey) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New(errNoKeys)
+	}
+	if keys[0].Private == nil {
+		return nil, errors.New(errNoSigningKey)
+	}
+
+	byID := make(map[string]SigningKey, len(keys))
+	for _, key := range keys {
+		if _, ok := byID[key.ID]; ok {
+			return nil, fmt.Errorf(errDuplicateKeyID+": %s", key.ID)
+		}
+		byID[key.ID] = key
+	}
+
+	return &KeySet{
+		signing: keys[0],
+		keys:    keys,
+		byID:    byID,
+	}, nil
+}
+
+// NewLegacyKeySet returns a set that signs and verifies tokens with the shared
+// secret only. It has no keys to publish.
+func NewLegacyKeySet(secret string) (*KeySet, error) {
+	if secret == "" {
+		return nil, errors.New(errEmptyLegacySecret)
+	}
+
+	return &KeySet{
+		byID:         map[string]SigningKey{},
+		legacySecret: []byte(secret),
+	}, nil
+}
+
+// WithLegacySecret returns a copy of the set that also accepts tokens without
+// a kid header signed with the shared secret. New tokens are still signed with
+// the signing key, so the secret can be dropped once the tokens it signed have
+// expired.
+func (s *KeySet) WithLegacySecret(secret string) *KeySet {
+	legacy := *s
+	legacy.legacySecret = []byte(secret)
+	return &legacy
+}
+
+// Sign signs the token with the signing key and sets its kid header. A legacy
+// set signs the token with its secret and sets no kid header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if len(s.keys) == 0 {
+		return jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(s.legacySecret)
+	}
+
+	token := jwt.NewWithClaims(s.signing.Method, claims)
+	token.Header["kid"] = s.signing.ID
+
+	return token.SignedString(s.signing.Private)
+}
+
+// VerificationKey returns the public key of the token's kid, or the legacy
+// secret for tokens without a kid, it's meant to be used as a jwt.Keyfunc.
+func (s *KeySet) VerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		if s.legacySecret == nil {
+			return nil, errors.New(errMissingKeyID)
+		}
+		if token.Method.Alg() != jwt.SigningMethodHS512.Alg() {
+			return nil, fmt.Errorf(errUnexpectedSigningMethod+": %v", token.Header["alg"])
+		}
+		return s.legacySecret, nil
+	}
+
+	key, ok := s.byID[kid]
+	if !ok {
+		return nil, fmt.Errorf(errUnknownKeyID+": %s", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf(errUnexpectedSigningMethod+": %v", token.Header["alg"])
+	}
+
+	return key.Public, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the set, signing key first.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := JWK{
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: key.Method.Alg(),
+		}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
@@ -0,0 +1,352 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/dgrijalva/jwt-go"
+	"testing"
+)
+
+func TestParseSigningKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		id          string
+		data        []byte
+		wantMethod  string
+		wantPrivate bool
+		wantErr     bool
+	}{
+		{
+			name:        "RSA PKCS#8 private key",
+			id:          "rsa",
+			data:        pemPKCS8(t, rsaKey),
+			wantMethod:  "RS256",
+			wantPrivate: true,
+		},
+		{
+			name:        "RSA PKCS#1 private key",
+			id:          "rsa",
+			data:        pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}),
+			wantMethod:  "RS256",
+			wantPrivate: true,
+		},
+		{
+			name:       "RSA public key",
+			id:         "rsa",
+			data:       pemPKIX(t, &rsaKey.PublicKey),
+			wantMethod: "RS256",
+		},
+		{
+			name:        "Ed25519 private key",
+			id:          "ed",
+			data:        pemPKCS8(t, edPrivate),
+			wantMethod:  "EdDSA",
+			wantPrivate: true,
+		},
+		{
+			name:       "Ed25519 public key",
+			id:         "ed",
+			data:       pemPKIX(t, edPublic),
+			wantMethod: "EdDSA",
+		},
+		{
+			name:    "short RSA key",
+			id:      "rsa",
+			data:    pemPKCS8(t, shortRSAKey),
+			wantErr: true,
+		},
+		{
+			name:    "empty id",
+			id:      "",
+			data:    pemPKCS8(t, edPrivate),
+			wantErr: true,
+		},
+		{
+			name:    "not PEM",
+			id:      "ed",
+			data:    []byte("not a key"),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported block",
+			id:      "ed",
+			data:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1}}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseSigningKey(tt.id, tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParseSigningKey() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSigningKey() error = %v", err)
+			}
+
+			if key.ID != tt.id {
+				t.Errorf("ID = %q, want %q", key.ID, tt.id)
+			}
+			if key.Method.Alg() != tt.wantMethod {
+				t.Errorf("Method = %q, want %q", key.Method.Alg(), tt.wantMethod)
+			}
+			if (key.Private != nil) != tt.wantPrivate {
+				t.Errorf("Private = %v, want a private key: %v", key.Private, tt.wantPrivate)
+			}
+			if key.Public == nil {
+				t.Error("Public = nil")
+			}
+		})
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaSigningKey := SigningKey{ID: "rsa", Method: jwt.SigningMethodRS256, Private: rsaKey, Public: &rsaKey.PublicKey}
+	edSigningKey := SigningKey{ID: "ed", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic}
+	edPublicKey := SigningKey{ID: "ed", Method: SigningMethodEdDSA, Public: edPublic}
+
+	tests := []struct {
+		name    string
+		signer  []SigningKey
+		parser  []SigningKey
+		wantErr bool
+	}{
+		{
+			name:   "RS256",
+			signer: []SigningKey{rsaSigningKey},
+			parser: []SigningKey{rsaSigningKey},
+		},
+		{
+			name:   "EdDSA",
+			signer: []SigningKey{edSigningKey},
+			parser: []SigningKey{edSigningKey},
+		},
+		{
+			name:   "retired key",
+			signer: []SigningKey{edSigningKey},
+			parser: []SigningKey{rsaSigningKey, edPublicKey},
+		},
+		{
+			name:    "unknown kid",
+			signer:  []SigningKey{edSigningKey},
+			parser:  []SigningKey{rsaSigningKey},
+			wantErr: true,
+		},
+		{
+			name:    "kid of a key with another algorithm",
+			signer:  []SigningKey{{ID: "rsa", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic}},
+			parser:  []SigningKey{rsaSigningKey},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewKeySet(tt.signer...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parser, err := NewKeySet(tt.parser...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signed, err := signer.Sign(jwt.StandardClaims{Subject: "user"})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			var claims jwt.StandardClaims
+			_, err = jwt.ParseWithClaims(signed, &claims, parser.VerificationKey)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParseWithClaims() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWithClaims() error = %v", err)
+			}
+			if claims.Subject != "user" {
+				t.Errorf("Subject = %q, want %q", claims.Subject, "user")
+			}
+		})
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingKey := SigningKey{ID: "ed", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic}
+	publicKey := SigningKey{ID: "old", Method: SigningMethodEdDSA, Public: edPublic}
+
+	tests := []struct {
+		name    string
+		keys    []SigningKey
+		wantErr bool
+	}{
+		{name: "signing key first", keys: []SigningKey{signingKey, publicKey}},
+		{name: "no keys", keys: nil, wantErr: true},
+		{name: "public key first", keys: []SigningKey{publicKey, signingKey}, wantErr: true},
+		{name: "duplicate id", keys: []SigningKey{signingKey, signingKey}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeySet(tt.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeySet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := NewKeySet(
+		SigningKey{ID: "ed", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic},
+		SigningKey{ID: "rsa", Method: jwt.SigningMethodRS256, Public: &rsaKey.PublicKey},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS() has %d keys, want 2", len(jwks.Keys))
+	}
+
+	edJWK, rsaJWK := jwks.Keys[0], jwks.Keys[1]
+	if edJWK.KeyID != "ed" || edJWK.KeyType != "OKP" || edJWK.Curve != "Ed25519" || edJWK.Algorithm != "EdDSA" || edJWK.Use != "sig" || edJWK.X == "" {
+		t.Errorf("Ed25519 JWK = %+v", edJWK)
+	}
+	if rsaJWK.KeyID != "rsa" || rsaJWK.KeyType != "RSA" || rsaJWK.Algorithm != "RS256" || rsaJWK.Use != "sig" || rsaJWK.N == "" || rsaJWK.E != "AQAB" {
+		t.Errorf("RSA JWK = %+v", rsaJWK)
+	}
+}
+
+func pemPKCS8(t *testing.T, key any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func pemPKIX(t *testing.T, key any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestLegacySecret(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edKeys, err := NewKeySet(SigningKey{ID: "ed", Method: SigningMethodEdDSA, Private: edPrivate, Public: edPublic})
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyKeys, err := NewLegacyKeySet("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherLegacyKeys, err := NewLegacyKeySet("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs256Token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{Subject: "user"}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(keys *KeySet) string {
+		signed, err := keys.Sign(jwt.StandardClaims{Subject: "user"})
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		parser  *KeySet
+		wantErr bool
+	}{
+		{name: "legacy set", token: sign(legacyKeys), parser: legacyKeys},
+		{name: "legacy token during migration", token: sign(legacyKeys), parser: edKeys.WithLegacySecret("secret")},
+		{name: "new token during migration", token: sign(edKeys), parser: edKeys.WithLegacySecret("secret")},
+		{name: "legacy token after migration", token: sign(legacyKeys), parser: edKeys, wantErr: true},
+		{name: "wrong secret", token: sign(otherLegacyKeys), parser: legacyKeys, wantErr: true},
+		{name: "other algorithm", token: hs256Token, parser: legacyKeys, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims jwt.StandardClaims
+			_, err := jwt.ParseWithClaims(tt.token, &claims, tt.parser.VerificationKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWithClaims() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && claims.Subject != "user" {
+				t.Errorf("Subject = %q, want %q", claims.Subject, "user")
+			}
+		})
+	}
+
+	if jwks := legacyKeys.JWKS(); len(jwks.Keys) != 0 {
+		t.Errorf("legacy JWKS() has %d keys, want none", len(jwks.Keys))
+	}
+	if _, err := NewLegacyKeySet(""); err == nil {
+		t.Error("NewLegacyKeySet(\"\") error = nil, want an error")
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const revocationCleanupInterval = 10 * time.Minute
+
+// RevocationStore keeps the ids of revoked tokens. An id only has to be kept
+// until expiresAt, the token is rejected as expired after that anyway.
+type RevocationStore interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// MemoryRevocationStore keeps revoked ids in memory. It's only suitable for a
+// single instance of the service, the ids are lost on restart.
+type MemoryRevocationStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expiresAt.After(s.revoked[tokenID]) {
+		s.revoked[tokenID] = expiresAt
+	}
+
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	expiresAt, ok := s.revoked[tokenID]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryRevocationStore) DeleteExpired(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for tokenID, expiresAt := range s.revoked {
+		if !now.Before(expiresAt) {
+			delete(s.revoked, tokenID)
+		}
+	}
+
+	return nil
+}
+
+// CleanupRevocations deletes expired ids from store every
+// revocationCleanupInterval until ctx is done.
+func CleanupRevocations(ctx context.Context, store RevocationStore) {
+	ticker := time.NewTicker(revocationCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := store.DeleteExpired(ctx); err != nil {
+			log.Printf(errDeletingExpiredRevocations+": %s\n", err)
+		}
+	}
+}
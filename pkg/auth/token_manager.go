@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
@@ -11,87 +12,251 @@ import (
 )
 
 const (
-	errUnexpectedSigningMethod = "unexpected signing method"
-	errAccessTokenUndefined    = "access token is undefined"
-	errGettingClaims           = "error getting user claims from token"
+	errUnexpectedSigningMethod    = "unexpected signing method"
+	errMissingTokenID             = "token id is missing"
+	errTokenExpired               = "token is expired"
+	errTokenNotValidYet           = "token is not valid yet"
+	errTokenIssuedInFuture        = "token is issued in the future"
+	errInvalidIssuer              = "token has an invalid issuer"
+	errInvalidAudience            = "token has an invalid audience"
+	errUnexpectedTokenType        = "unexpected token type"
+	errDeletingExpiredRevocations = "error deleting expired token revocations"
 
 	accessTokenPrefix = "Bearer "
+
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrAccessTokenUndefined = errors.New("access token is undefined")
+	ErrAccessTokenRevoked   = errors.New("access token is revoked")
+	ErrCheckingRevocation   = errors.New("error checking token revocation")
 )
 
 type TokenManager interface {
-	NewAccessToken(userID uuid.UUID) (string, error)
-	NewRefreshToken(userID uuid.UUID) (string, error)
-	ParseAccessToken(accessToken string) (string, error)
-	ParseRefreshToken(refreshToken string) (string, error)
+	NewAccessToken(userID uuid.UUID, sessionID uuid.UUID) (string, string, error)
+	NewRefreshToken(userID uuid.UUID, sessionID uuid.UUID) (string, error)
+	ParseAccessToken(ctx context.Context, accessToken string) (AccessClaims, error)
+	RevokeAccessToken(ctx context.Context, tokenID string) error
+	ParseRefreshToken(refreshToken string) (RefreshClaims, error)
+	HashRefreshToken(refreshToken string) (string, error)
+	IsValidRefreshToken(hashedRefreshToken string, refreshToken string) bool
+	JWKS() JWKS
+}
+
+// ClaimsConfig sets the iss and aud claims of issued tokens, parsed tokens
+// must carry the same ones. Leeway is the clock skew tolerated when checking
+// exp, nbf and iat.
+type ClaimsConfig struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// AccessClaims are the claims carried by an access token. SessionID and Scope
+// are optional, Scope is a space-separated list of scopes. Type is always
+// access, it keeps a refresh token from being accepted as an access token and
+// the other way round.
+type AccessClaims struct {
+	jwt.StandardClaims
+	Type      string `json:"typ"`
+	SessionID string `json:"sid,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// Scopes returns the scopes of the token.
+func (c AccessClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// RefreshClaims are the claims carried by a refresh token.
+type RefreshClaims struct {
+	jwt.StandardClaims
+	Type      string `json:"typ"`
+	SessionID string `json:"sid"`
 }
 
 type Manager struct {
 	accessTTL         time.Duration
 	refreshTTL        time.Duration
-	accessSigningKey  string
+	accessKeys        *KeySet
 	refreshSigningKey string
+	claims            ClaimsConfig
 	hasher            hash.Hasher
+	revocations       RevocationStore
 }
 
-func NewManager(accessTTL time.Duration, refreshTTL time.Duration, accessSigningKey string, refreshSigningKey string, hasher hash.Hasher) *Manager {
+func NewManager(accessTTL time.Duration, refreshTTL time.Duration, accessKeys *KeySet, refreshSigningKey string, claims ClaimsConfig, hasher hash.Hasher, revocations RevocationStore) *Manager {
 	return &Manager{
 		accessTTL:         accessTTL,
 		refreshTTL:        refreshTTL,
-		accessSigningKey:  accessSigningKey,
+		accessKeys:        accessKeys,
 		refreshSigningKey: refreshSigningKey,
+		claims:            claims,
 		hasher:            hasher,
+		revocations:       revocations,
 	}
 }
 
-func (m *Manager) newToken(userID uuid.UUID, ttl time.Duration, signingKey string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.StandardClaims{
-		ExpiresAt: time.Now().Add(ttl).Unix(),
-		IssuedAt:  time.Now().Unix(),
-		Subject:   userID.String(),
-	})
+func (m *Manager) newStandardClaims(userID uuid.UUID, ttl time.Duration) jwt.StandardClaims {
+	now := time.Now()
 
-	return token.SignedString([]byte(signingKey))
+	return jwt.StandardClaims{
+		Audience:  m.claims.Audience,
+		ExpiresAt: now.Add(ttl).Unix(),
+		Id:        uuid.NewString(),
+		IssuedAt:  now.Unix(),
+		Issuer:    m.claims.Issuer,
+		NotBefore: now.Unix(),
+		Subject:   userID.String(),
+	}
 }
 
-func (m *Manager) NewAccessToken(userID uuid.UUID) (string, error) {
-	return m.newToken(userID, m.accessTTL, m.accessSigningKey)
+// validateClaims checks the registered claims and the token type. jwt-go
+// checks the time claims without any leeway, so its own validation is
+// skipped in parseToken.
+func (m *Manager) validateClaims(claims jwt.StandardClaims, tokenType string, expectedType string) error {
+	now := time.Now()
+
+	if !claims.VerifyExpiresAt(now.Add(-m.claims.Leeway).Unix(), true) {
+		return errors.New(errTokenExpired)
+	}
+	if !claims.VerifyNotBefore(now.Add(m.claims.Leeway).Unix(), false) {
+		return errors.New(errTokenNotValidYet)
+	}
+	if !claims.VerifyIssuedAt(now.Add(m.claims.Leeway).Unix(), false) {
+		return errors.New(errTokenIssuedInFuture)
+	}
+	if !claims.VerifyIssuer(m.claims.Issuer, true) {
+		return errors.New(errInvalidIssuer)
+	}
+	if !claims.VerifyAudience(m.claims.Audience, true) {
+		return errors.New(errInvalidAudience)
+	}
+	if tokenType != expectedType {
+		return fmt.Errorf(errUnexpectedTokenType+": %q", tokenType)
+	}
+
+	return nil
 }
 
-func (m *Manager) NewRefreshToken(userID uuid.UUID) (string, error) {
-	return m.newToken(userID, m.refreshTTL, m.refreshSigningKey)
+// newToken signs a refresh token, refresh tokens never leave the service so
+// they are signed with a shared secret.
+func (m *Manager) newToken(claims jwt.Claims, signingKey string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+
+	return token.SignedString([]byte(signingKey))
 }
 
-func (m *Manager) parseToken(receivedToken string, signingKey string) (string, error) {
-	token, err := jwt.Parse(receivedToken, func(token *jwt.Token) (i interface{}, err error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf(errUnexpectedSigningMethod+": %v", token.Header["alg"])
-		}
+// NewAccessToken returns a new access token and its id, the id is needed to
+// revoke the token.
+func (m *Manager) NewAccessToken(userID uuid.UUID, sessionID uuid.UUID) (string, string, error) {
+	claims := AccessClaims{
+		StandardClaims: m.newStandardClaims(userID, m.accessTTL),
+		Type:           tokenTypeAccess,
+		SessionID:      sessionID.String(),
+	}
 
-		return []byte(signingKey), nil
-	})
+	accessToken, err := m.accessKeys.Sign(claims)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		return claims["sub"].(string), nil
+	return accessToken, claims.Id, nil
+}
+
+func (m *Manager) NewRefreshToken(userID uuid.UUID, sessionID uuid.UUID) (string, error) {
+	return m.newToken(RefreshClaims{
+		StandardClaims: m.newStandardClaims(userID, m.refreshTTL),
+		Type:           tokenTypeRefresh,
+		SessionID:      sessionID.String(),
+	}, m.refreshSigningKey)
+}
+
+func (m *Manager) parseToken(receivedToken string, claims jwt.Claims, keyFunc jwt.Keyfunc) error {
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(receivedToken, claims, keyFunc)
+
+	return err
+}
+
+func (m *Manager) refreshSigningKeyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf(errUnexpectedSigningMethod+": %v", token.Header["alg"])
 	}
 
-	return "", fmt.Errorf(errGettingClaims)
+	return []byte(m.refreshSigningKey), nil
 }
 
-func (m *Manager) ParseAccessToken(accessToken string) (string, error) {
+// ParseAccessToken parses and verifies an access token, checks its exp, nbf,
+// iat, iss, aud and typ claims and makes sure it hasn't been revoked.
+func (m *Manager) ParseAccessToken(ctx context.Context, accessToken string) (AccessClaims, error) {
 	if accessToken == "" {
-		return "", errors.New(errAccessTokenUndefined)
+		return AccessClaims{}, ErrAccessTokenUndefined
 	}
 
 	if strings.HasPrefix(accessToken, accessTokenPrefix) {
 		accessToken = strings.TrimPrefix(accessToken, accessTokenPrefix)
 	}
 
-	return m.parseToken(accessToken, m.accessSigningKey)
+	claims := AccessClaims{}
+	if err := m.parseToken(accessToken, &claims, m.accessKeys.VerificationKey); err != nil {
+		return AccessClaims{}, err
+	}
+
+	if err := m.validateClaims(claims.StandardClaims, claims.Type, tokenTypeAccess); err != nil {
+		return AccessClaims{}, err
+	}
+
+	if claims.Id == "" {
+		return AccessClaims{}, errors.New(errMissingTokenID)
+	}
+
+	revoked, err := m.revocations.IsRevoked(ctx, claims.Id)
+	if err != nil {
+		return AccessClaims{}, fmt.Errorf("%w: %w", ErrCheckingRevocation, err)
+	}
+	if revoked {
+		return AccessClaims{}, ErrAccessTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// RevokeAccessToken revokes the access token with the id. It's kept revoked
+// for the access token TTL and the leeway, by then the token has expired.
+func (m *Manager) RevokeAccessToken(ctx context.Context, tokenID string) error {
+	return m.revocations.Revoke(ctx, tokenID, time.Now().Add(m.accessTTL+m.claims.Leeway))
+}
+
+// ParseRefreshToken parses and verifies a refresh token, like ParseAccessToken
+// it checks the registered claims and the token type.
+func (m *Manager) ParseRefreshToken(refreshToken string) (RefreshClaims, error) {
+	claims := RefreshClaims{}
+	if err := m.parseToken(refreshToken, &claims, m.refreshSigningKeyFunc); err != nil {
+		return RefreshClaims{}, err
+	}
+
+	if err := m.validateClaims(claims.StandardClaims, claims.Type, tokenTypeRefresh); err != nil {
+		return RefreshClaims{}, err
+	}
+
+	return claims, nil
+}
+
+// HashRefreshToken returns the hash of a refresh token to store in place of
+// the token itself.
+func (m *Manager) HashRefreshToken(refreshToken string) (string, error) {
+	return m.hasher.Hash(refreshToken)
+}
+
+// IsValidRefreshToken reports whether refreshToken matches the stored hash.
+func (m *Manager) IsValidRefreshToken(hashedRefreshToken string, refreshToken string) bool {
+	return m.hasher.IsValidData(hashedRefreshToken, refreshToken)
 }
 
-func (m *Manager) ParseRefreshToken(refreshToken string) (string, error) {
-	return m.parseToken(refreshToken, m.refreshSigningKey)
+// JWKS returns the public keys access tokens are verified with.
+func (m *Manager) JWKS() JWKS {
+	return m.accessKeys.JWKS()
 }
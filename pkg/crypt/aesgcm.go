@@ -0,0 +1,69 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+const ErrCiphertextTooShort = "ciphertext is too short"
+
+// Cipher encrypts secrets that have to be stored but, unlike passwords, must
+// be readable again.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// AESGCMCipher encrypts with AES-256-GCM. The key is derived from a passphrase
+// with SHA-256, the random nonce is stored in front of the ciphertext.
+type AESGCMCipher struct {
+	aead cipher.AEAD
+}
+
+func NewAESGCMCipher(key string) (*AESGCMCipher, error) {
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCMCipher{aead: aead}, nil
+}
+
+func (c *AESGCMCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(c.aead.Seal(nonce, nonce, []byte(plaintext), nil)), nil
+}
+
+func (c *AESGCMCipher) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New(ErrCiphertextTooShort)
+	}
+
+	nonce, data := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
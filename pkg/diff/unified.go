@@ -0,0 +1,245 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	contextLines = 3
+	// maxEditDistance bounds the work of shortestEdit, texts that differ in
+	// more lines are diffed as a replacement of all the differing lines.
+	maxEditDistance = 1000
+)
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	text string
+	// aLine and bLine are the zero-based positions in a and b right before
+	// the op is applied.
+	aLine int
+	bLine int
+}
+
+// Unified returns a line-based unified diff between a and b with fromName and
+// toName in the file headers. An empty string is returned when the texts are
+// equal. The diff is minimal unless the texts differ in more than
+// maxEditDistance lines.
+func Unified(fromName string, toName string, a string, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	for _, hunk := range hunks(ops) {
+		writeHunk(&builder, hunk)
+	}
+
+	return builder.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes the shortest edit script between a and b. The common
+// prefix and suffix are equal lines whatever the script is, so only the lines
+// between them are searched.
+func diffLines(a []string, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []op
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{kind: opEqual, text: a[i], aLine: i, bLine: i})
+	}
+
+	ops = append(ops, shortestEdit(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)...)
+
+	for i := suffix; i > 0; i-- {
+		x, y := len(a)-i, len(b)-i
+		ops = append(ops, op{kind: opEqual, text: a[x], aLine: x, bLine: y})
+	}
+
+	return ops
+}
+
+// shortestEdit computes the shortest edit script between a and b with the
+// Myers algorithm, start is the position of a and b in the whole texts. The
+// search takes O((N+M)·D) time and keeps O(D²) of its state for backtracking,
+// so it gives up after maxEditDistance edits and replaces all of a with all of
+// b instead.
+func shortestEdit(a []string, b []string, start int) []op {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxEditDistance)
+	offset := maxD + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds v[-d-1..d+1] as it was before step d, that's all
+	// backtracking from step d reads.
+	var trace [][]int
+	found := false
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break search
+			}
+		}
+	}
+
+	if !found {
+		return replace(a, b, start)
+	}
+
+	var reversed []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, op{kind: opEqual, text: a[x], aLine: start + x, bLine: start + y})
+		}
+
+		if d == 0 {
+			break
+		}
+
+		if x == prevX {
+			y--
+			reversed = append(reversed, op{kind: opInsert, text: b[y], aLine: start + x, bLine: start + y})
+		} else {
+			x--
+			reversed = append(reversed, op{kind: opDelete, text: a[x], aLine: start + x, bLine: start + y})
+		}
+	}
+
+	ops := make([]op, len(reversed))
+	for i, o := range reversed {
+		ops[len(reversed)-1-i] = o
+	}
+
+	return ops
+}
+
+// replace deletes all lines of a and inserts all lines of b.
+func replace(a []string, b []string, start int) []op {
+	ops := make([]op, 0, len(a)+len(b))
+	for i, line := range a {
+		ops = append(ops, op{kind: opDelete, text: line, aLine: start + i, bLine: start})
+	}
+	for i, line := range b {
+		ops = append(ops, op{kind: opInsert, text: line, aLine: start + len(a), bLine: start + i})
+	}
+
+	return ops
+}
+
+// hunks groups changed ops together with up to contextLines of unchanged
+// lines around them.
+func hunks(ops []op) [][]op {
+	var result [][]op
+
+	start, end := -1, -1
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+
+		from := max(i-contextLines, 0)
+		if start != -1 && from <= end {
+			end = min(i+contextLines+1, len(ops))
+			continue
+		}
+
+		if start != -1 {
+			result = append(result, ops[start:end])
+		}
+		start = from
+		end = min(i+contextLines+1, len(ops))
+	}
+
+	if start != -1 {
+		result = append(result, ops[start:end])
+	}
+
+	return result
+}
+
+func writeHunk(builder *strings.Builder, hunk []op) {
+	aStart, bStart := hunk[0].aLine, hunk[0].bLine
+	aCount, bCount := 0, 0
+	for _, o := range hunk {
+		if o.kind != opInsert {
+			aCount++
+		}
+		if o.kind != opDelete {
+			bCount++
+		}
+	}
+
+	// Unified diff line numbers are one-based, except that an empty range
+	// points at the line right before it.
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+
+	builder.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount))
+	for _, o := range hunk {
+		builder.WriteByte(byte(o.kind))
+		builder.WriteString(o.text)
+		builder.WriteByte('\n')
+	}
+}
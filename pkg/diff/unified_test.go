@@ -0,0 +1,174 @@
+package diff
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\nb\n",
+			want: "--- from\n+++ to\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "to empty",
+			a:    "a\nb\n",
+			b:    "",
+			want: "--- from\n+++ to\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name: "changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nx\nc\n",
+			want: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "x\n2\n3\n4\n5\n6\n7\n8\n9\ny\n",
+			want: "--- from\n+++ to\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+y\n",
+		},
+		{
+			name: "merged hunks",
+			a:    "1\n2\n3\n4\n5\n6\n",
+			b:    "x\n2\n3\n4\n5\ny\n",
+			want: "--- from\n+++ to\n@@ -1,6 +1,6 @@\n-1\n+x\n 2\n 3\n 4\n 5\n-6\n+y\n",
+		},
+		{
+			name: "inserted line",
+			a:    "1\n2\n3\n4\n5\n",
+			b:    "1\n2\n3\nx\n4\n5\n",
+			want: "--- from\n+++ to\n@@ -1,5 +1,6 @@\n 1\n 2\n 3\n+x\n 4\n 5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("from", "to", tt.a, tt.b)
+			if got != tt.want {
+				t.Errorf("Unified() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		a := randomLines(rng, rng.Intn(30))
+		b := randomLines(rng, rng.Intn(30))
+
+		ops := diffLines(a, b)
+		checkOps(t, a, b, ops)
+
+		edits := 0
+		for _, o := range ops {
+			if o.kind != opEqual {
+				edits++
+			}
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); edits != want {
+			t.Fatalf("diffLines(%q, %q) has %d edits, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesFallsBackToReplace(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxEditDistance; i++ {
+		a = append(a, "a"+strconv.Itoa(i))
+		b = append(b, "b"+strconv.Itoa(i))
+	}
+	a = append([]string{"same"}, append(a, "same")...)
+	b = append([]string{"same"}, append(b, "same")...)
+
+	ops := diffLines(a, b)
+	checkOps(t, a, b, ops)
+
+	for i, o := range ops[1 : len(ops)-1] {
+		want := opDelete
+		if i >= maxEditDistance {
+			want = opInsert
+		}
+		if o.kind != want {
+			t.Fatalf("op %d is %q, want %q", i+1, o.kind, want)
+		}
+	}
+}
+
+// checkOps checks that applying ops to a gives b and that every op is at the
+// position it's applied to.
+func checkOps(t *testing.T, a []string, b []string, ops []op) {
+	t.Helper()
+
+	x, y := 0, 0
+	for _, o := range ops {
+		if o.aLine != x || o.bLine != y {
+			t.Fatalf("op %q %q is at %d,%d, want %d,%d", o.kind, o.text, o.aLine, o.bLine, x, y)
+		}
+
+		switch o.kind {
+		case opEqual:
+			if a[x] != o.text || b[y] != o.text {
+				t.Fatalf("equal op %q doesn't match %q and %q", o.text, a[x], b[y])
+			}
+			x++
+			y++
+		case opDelete:
+			if a[x] != o.text {
+				t.Fatalf("delete op %q doesn't match %q", o.text, a[x])
+			}
+			x++
+		case opInsert:
+			if b[y] != o.text {
+				t.Fatalf("insert op %q doesn't match %q", o.text, b[y])
+			}
+			y++
+		}
+	}
+
+	if x != len(a) || y != len(b) {
+		t.Fatalf("ops end at %d,%d, want %d,%d", x, y, len(a), len(b))
+	}
+}
+
+func randomLines(rng *rand.Rand, n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = string(rune('a' + rng.Intn(4)))
+	}
+	return lines
+}
+
+func lcsLength(a []string, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
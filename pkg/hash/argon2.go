@@ -0,0 +1,148 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+// Salt and key lengths recommended by RFC 9106.
+const (
+	Argon2SaltLength = 16
+	Argon2KeyLength  = 32
+)
+
+var (
+	errInvalidArgon2Hash         = errors.New("invalid argon2id hash")
+	errIncompatibleArgon2Version = errors.New("incompatible argon2 version")
+)
+
+// Argon2Params are the parameters of Argon2id (RFC 9106). Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Argon2idHasher hashes passwords with Argon2id. Hashes are encoded in the PHC
+// string format, e.g. $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>, so that
+// every hash records the parameters it was made with and stays verifiable
+// after the parameters change. Hashes made by BcryptHasher are verified too,
+// NeedsRehash reports them as outdated.
+type Argon2idHasher struct {
+	params Argon2Params
+	bcrypt *BcryptHasher
+}
+
+func NewArgon2idHasher(params Argon2Params) *Argon2idHasher {
+	return &Argon2idHasher{
+		params: params,
+		bcrypt: NewBcryptHasher(),
+	}
+}
+
+func (h *Argon2idHasher) Hash(data string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(data), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return argon2Hash{params: h.params, salt: salt, key: key}.String(), nil
+}
+
+func (h *Argon2idHasher) IsValidData(hashedData, data string) bool {
+	if isBcryptHash(hashedData) {
+		return h.bcrypt.IsValidData(hashedData, data)
+	}
+
+	parsed, err := parseArgon2Hash(hashedData)
+	if err != nil {
+		return false
+	}
+
+	p := parsed.params
+	key := argon2.IDKey([]byte(data), parsed.salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return subtle.ConstantTimeCompare(key, parsed.key) == 1
+}
+
+// NeedsRehash reports whether the hash was made with bcrypt or with weaker
+// parameters than those of the hasher.
+func (h *Argon2idHasher) NeedsRehash(hashedData string) bool {
+	if isBcryptHash(hashedData) {
+		return true
+	}
+
+	parsed, err := parseArgon2Hash(hashedData)
+	if err != nil {
+		return false
+	}
+
+	p := parsed.params
+	return p.Memory < h.params.Memory ||
+		p.Iterations < h.params.Iterations ||
+		p.Parallelism < h.params.Parallelism ||
+		p.SaltLength < h.params.SaltLength ||
+		p.KeyLength < h.params.KeyLength
+}
+
+type argon2Hash struct {
+	params Argon2Params
+	salt   []byte
+	key    []byte
+}
+
+func (a argon2Hash) String() string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.params.Memory,
+		a.params.Iterations,
+		a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(a.salt),
+		base64.RawStdEncoding.EncodeToString(a.key),
+	)
+}
+
+func parseArgon2Hash(s string) (argon2Hash, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return argon2Hash{}, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2Hash{}, errInvalidArgon2Hash
+	}
+	if version != argon2.Version {
+		return argon2Hash{}, errIncompatibleArgon2Version
+	}
+
+	var params Argon2Params
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Iterations == 0 || params.Parallelism == 0 {
+		return argon2Hash{}, errInvalidArgon2Hash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Hash{}, errInvalidArgon2Hash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2Hash{}, errInvalidArgon2Hash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return argon2Hash{params: params, salt: salt, key: key}, nil
+}
@@ -0,0 +1,148 @@
+package hash
+
+import (
+	"strings"
+	"testing"
+)
+
+// testArgon2Params are cheap parameters that keep the tests fast.
+var testArgon2Params = Argon2Params{
+	Memory:      64,
+	Iterations:  2,
+	Parallelism: 2,
+	SaltLength:  Argon2SaltLength,
+	KeyLength:   Argon2KeyLength,
+}
+
+func TestArgon2idHasherRoundTrip(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params)
+
+	hashed, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=64,t=2,p=2$") {
+		t.Errorf("Hash() = %q, want the PHC string format", hashed)
+	}
+
+	parsed, err := parseArgon2Hash(hashed)
+	if err != nil {
+		t.Fatalf("parseArgon2Hash() error = %v", err)
+	}
+	if parsed.params != testArgon2Params {
+		t.Errorf("parsed params = %+v, want %+v", parsed.params, testArgon2Params)
+	}
+	if parsed.String() != hashed {
+		t.Errorf("String() = %q, want %q", parsed.String(), hashed)
+	}
+
+	if !hasher.IsValidData(hashed, "correct horse") {
+		t.Error("IsValidData() of the hashed data = false")
+	}
+	if hasher.IsValidData(hashed, "wrong horse") {
+		t.Error("IsValidData() of other data = true")
+	}
+	if hasher.NeedsRehash(hashed) {
+		t.Error("NeedsRehash() of a hash with the same params = true")
+	}
+
+	other, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hashed {
+		t.Error("Hash() returned the same hash twice, the salt isn't random")
+	}
+}
+
+func TestArgon2idHasherBcrypt(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params)
+
+	hashed, err := NewBcryptHasher().Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasher.IsValidData(hashed, "correct horse") {
+		t.Error("IsValidData() of a bcrypt hash = false")
+	}
+	if hasher.IsValidData(hashed, "wrong horse") {
+		t.Error("IsValidData() of a bcrypt hash and other data = true")
+	}
+	if !hasher.NeedsRehash(hashed) {
+		t.Error("NeedsRehash() of a bcrypt hash = false")
+	}
+}
+
+func TestArgon2idHasherNeedsRehash(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params)
+
+	tests := []struct {
+		name   string
+		params func(p *Argon2Params)
+		want   bool
+	}{
+		{name: "same", params: func(p *Argon2Params) {}, want: false},
+		{name: "less memory", params: func(p *Argon2Params) { p.Memory = 32 }, want: true},
+		{name: "fewer iterations", params: func(p *Argon2Params) { p.Iterations = 1 }, want: true},
+		{name: "less parallelism", params: func(p *Argon2Params) { p.Parallelism = 1 }, want: true},
+		{name: "shorter salt", params: func(p *Argon2Params) { p.SaltLength = 8 }, want: true},
+		{name: "shorter key", params: func(p *Argon2Params) { p.KeyLength = 16 }, want: true},
+		{name: "more memory", params: func(p *Argon2Params) { p.Memory = 128 }, want: false},
+		{name: "more iterations", params: func(p *Argon2Params) { p.Iterations = 3 }, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := testArgon2Params
+			tt.params(&params)
+
+			hashed, err := NewArgon2idHasher(params).Hash("correct horse")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := hasher.NeedsRehash(hashed); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+			if !hasher.IsValidData(hashed, "correct horse") {
+				t.Error("IsValidData() of a hash with other params = false")
+			}
+		})
+	}
+}
+
+func TestParseArgon2Hash(t *testing.T) {
+	const (
+		salt = "c29tZXNhbHRzb21lc2FsdA"
+		key  = "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	)
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr bool
+	}{
+		{name: "valid", hash: "$argon2id$v=19$m=64,t=2,p=2$" + salt + "$" + key},
+		{name: "argon2i", hash: "$argon2i$v=19$m=64,t=2,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "other version", hash: "$argon2id$v=16$m=64,t=2,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "no version", hash: "$argon2id$m=64,t=2,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "no iterations", hash: "$argon2id$v=19$m=64,t=0,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "no parallelism", hash: "$argon2id$v=19$m=64,t=2,p=0$" + salt + "$" + key, wantErr: true},
+		{name: "bad params", hash: "$argon2id$v=19$t=2,m=64,p=2$" + salt + "$" + key, wantErr: true},
+		{name: "bad salt", hash: "$argon2id$v=19$m=64,t=2,p=2$!!!$" + key, wantErr: true},
+		{name: "empty key", hash: "$argon2id$v=19$m=64,t=2,p=2$" + salt + "$", wantErr: true},
+		{name: "bcrypt", hash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", wantErr: true},
+		{name: "empty", hash: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseArgon2Hash(tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseArgon2Hash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
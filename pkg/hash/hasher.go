@@ -2,13 +2,26 @@ package hash
 
 import (
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
+// bcryptMaxLength is the number of bytes bcrypt hashes, it ignores the rest.
+const bcryptMaxLength = 72
+
 type Hasher interface {
 	Hash(data string) (string, error)
 	IsValidData(hashedData, data string) bool
 }
 
+// Rehasher is implemented by hashers that can tell whether a hash was made
+// with another algorithm or outdated parameters and should be replaced.
+type Rehasher interface {
+	NeedsRehash(hashedData string) bool
+}
+
+// BcryptHasher hashes data with bcrypt. bcrypt only hashes the first 72 bytes,
+// so longer data can't be hashed and never matches a hash, otherwise any data
+// with the same first 72 bytes would match it.
 type BcryptHasher struct{}
 
 func NewBcryptHasher() *BcryptHasher {
@@ -24,5 +37,12 @@ func (b *BcryptHasher) Hash(data string) (string, error) {
 }
 
 func (b *BcryptHasher) IsValidData(hashedData, data string) bool {
+	if len(data) > bcryptMaxLength {
+		return false
+	}
 	return bcrypt.CompareHashAndPassword([]byte(hashedData), []byte(data)) == nil
 }
+
+func isBcryptHash(hashedData string) bool {
+	return strings.HasPrefix(hashedData, "$2a$") || strings.HasPrefix(hashedData, "$2b$") || strings.HasPrefix(hashedData, "$2y$")
+}
@@ -0,0 +1,39 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HMACHasher hashes data with HMAC-SHA256. Unlike bcrypt the hash is
+// deterministic and fast, so it suits random high-entropy data such as
+// tokens, not passwords.
+type HMACHasher struct {
+	key []byte
+}
+
+func NewHMACHasher(key string) *HMACHasher {
+	return &HMACHasher{
+		key: []byte(key),
+	}
+}
+
+func (h *HMACHasher) Hash(data string) (string, error) {
+	return hex.EncodeToString(h.sum(data)), nil
+}
+
+// IsValidData compares the hashes in constant time.
+func (h *HMACHasher) IsValidData(hashedData, data string) bool {
+	decoded, err := hex.DecodeString(hashedData)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(decoded, h.sum(data))
+}
+
+func (h *HMACHasher) sum(data string) []byte {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
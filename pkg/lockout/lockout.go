@@ -0,0 +1,120 @@
+package lockout
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	errDeletingExpiredFailures = "error deleting expired failures"
+
+	cleanupInterval = 10 * time.Minute
+)
+
+// Policy describes when a key is locked out. The first MaxFailures failures
+// are free, the next one locks the key out for BaseDelay and every further
+// failure doubles the lockout up to MaxDelay. Failures are forgotten Window
+// after the last of them.
+type Policy struct {
+	MaxFailures int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Window      time.Duration
+}
+
+// Delay returns the lockout after the given number of failures.
+func (p Policy) Delay(failures int) time.Duration {
+	if failures <= p.MaxFailures {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.MaxFailures + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, p.MaxDelay)
+}
+
+// Store keeps failures and lockouts of keys. A key only has to be kept until
+// expiresAt, after that its failures are forgotten.
+type Store interface {
+	// LockedUntil returns the end of the lockout of the key, it's zero if the
+	// key has never been locked out.
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// AddFailure counts a failure of the key and returns the number of its
+	// failures.
+	AddFailure(ctx context.Context, key string, expiresAt time.Time) (int, error)
+	// Lock locks the key out until the given time unless it's already locked
+	// out for longer.
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) error
+}
+
+// Limiter locks keys out after repeated failures according to its policy.
+type Limiter struct {
+	store  Store
+	policy Policy
+}
+
+func NewLimiter(store Store, policy Policy) *Limiter {
+	return &Limiter{
+		store:  store,
+		policy: policy,
+	}
+}
+
+// RetryAfter returns how long the key stays locked out, it's zero if the key
+// isn't locked out.
+func (l *Limiter) RetryAfter(ctx context.Context, key string) (time.Duration, error) {
+	lockedUntil, err := l.store.LockedUntil(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(time.Until(lockedUntil), 0), nil
+}
+
+// Fail counts a failure of the key and locks it out if it has failed too many
+// times.
+func (l *Limiter) Fail(ctx context.Context, key string) error {
+	now := time.Now()
+
+	failures, err := l.store.AddFailure(ctx, key, now.Add(l.policy.Window))
+	if err != nil {
+		return err
+	}
+
+	delay := l.policy.Delay(failures)
+	if delay == 0 {
+		return nil
+	}
+
+	return l.store.Lock(ctx, key, now.Add(delay))
+}
+
+// Reset forgets the failures of the key.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, key)
+}
+
+// Cleanup deletes expired keys from store every cleanupInterval until ctx is
+// done.
+func Cleanup(ctx context.Context, store Store) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := store.DeleteExpired(ctx); err != nil {
+			log.Printf(errDeletingExpiredFailures+": %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPolicyDelay(t *testing.T) {
+	policy := Policy{
+		MaxFailures: 3,
+		BaseDelay:   time.Second,
+		MaxDelay:    10 * time.Second,
+		Window:      time.Hour,
+	}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 1, want: 0},
+		{failures: 3, want: 0},
+		{failures: 4, want: time.Second},
+		{failures: 5, want: 2 * time.Second},
+		{failures: 6, want: 4 * time.Second},
+		{failures: 7, want: 8 * time.Second},
+		{failures: 8, want: 10 * time.Second},
+		{failures: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(NewMemoryStore(), Policy{
+		MaxFailures: 2,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		Window:      time.Hour,
+	})
+
+	retryAfter := func(key string) time.Duration {
+		d, err := limiter.RetryAfter(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	fail := func(key string) {
+		if err := limiter.Fail(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fail("alice")
+	fail("alice")
+	if d := retryAfter("alice"); d != 0 {
+		t.Fatalf("RetryAfter() after 2 failures = %s, want 0", d)
+	}
+
+	fail("alice")
+	if d := retryAfter("alice"); d <= 0 || d > time.Minute {
+		t.Fatalf("RetryAfter() after 3 failures = %s, want up to 1m", d)
+	}
+
+	fail("alice")
+	if d := retryAfter("alice"); d <= time.Minute || d > 2*time.Minute {
+		t.Fatalf("RetryAfter() after 4 failures = %s, want between 1m and 2m", d)
+	}
+
+	if d := retryAfter("bob"); d != 0 {
+		t.Errorf("RetryAfter() of another key = %s, want 0", d)
+	}
+
+	if err := limiter.Reset(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if d := retryAfter("alice"); d != 0 {
+		t.Fatalf("RetryAfter() after Reset() = %s, want 0", d)
+	}
+
+	fail("alice")
+	if d := retryAfter("alice"); d != 0 {
+		t.Errorf("RetryAfter() after Reset() and a failure = %s, want 0", d)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	past := time.Now().Add(-time.Second)
+	future := time.Now().Add(time.Hour)
+
+	if _, err := store.AddFailure(ctx, "expired", past); err != nil {
+		t.Fatal(err)
+	}
+	failures, err := store.AddFailure(ctx, "expired", past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures != 1 {
+		t.Errorf("AddFailure() after an expired failure = %d, want 1", failures)
+	}
+
+	if _, err := store.AddFailure(ctx, "locked", future); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Lock(ctx, "locked", future); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Lock(ctx, "locked", past); err != nil {
+		t.Fatal(err)
+	}
+	lockedUntil, err := store.LockedUntil(ctx, "locked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lockedUntil.Equal(future) {
+		t.Errorf("LockedUntil() = %s, want %s, a shorter lockout mustn't replace a longer one", lockedUntil, future)
+	}
+
+	if err := store.DeleteExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.entries["expired"]; ok {
+		t.Error("DeleteExpired() kept an expired key")
+	}
+	if _, ok := store.entries["locked"]; !ok {
+		t.Error("DeleteExpired() deleted a key that hasn't expired")
+	}
+}
@@ -0,0 +1,105 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	failures    int
+	lockedUntil time.Time
+	expiresAt   time.Time
+}
+
+// MemoryStore keeps failures in memory. It's only suitable for a single
+// instance of the service, the failures are lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]entry),
+	}
+}
+
+func (s *MemoryStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.get(key)
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	return e.lockedUntil, nil
+}
+
+func (s *MemoryStore) AddFailure(ctx context.Context, key string, expiresAt time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, _ := s.get(key)
+	e.failures++
+	if expiresAt.After(e.expiresAt) {
+		e.expiresAt = expiresAt
+	}
+	s.entries[key] = e
+
+	return e.failures, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.get(key)
+	if !ok {
+		return nil
+	}
+
+	if until.After(e.lockedUntil) {
+		e.lockedUntil = until
+	}
+	if until.After(e.expiresAt) {
+		e.expiresAt = until
+	}
+	s.entries[key] = e
+
+	return nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpired(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	return nil
+}
+
+// get returns the entry of the key unless it has expired.
+func (s *MemoryStore) get(key string) (entry, bool) {
+	e, ok := s.entries[key]
+	if !ok || !time.Now().Before(e.expiresAt) {
+		return entry{}, false
+	}
+
+	return e, true
+}
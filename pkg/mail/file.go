@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// FileMailer appends messages to a file instead of sending them. It's meant
+// for development and tests.
+type FileMailer struct {
+	From string
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileMailer(from string, path string) *FileMailer {
+	return &FileMailer{
+		From: from,
+		Path: path,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message Message) error {
+	data, err := format(m.From, message)
+	if err != nil {
+		return fmt.Errorf(ErrWritingMail+": %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf(ErrWritingMail+": %w", err)
+	}
+	defer file.Close()
+
+	if _, err = file.Write(append(data, "\r\n"...)); err != nil {
+		return fmt.Errorf(ErrWritingMail+": %w", err)
+	}
+
+	return nil
+}
+
+// LogMailer writes messages to the log instead of sending them. Messages
+// carry secret tokens, so it must never be used in production.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	log.Printf("mail to %s, subject %q:\n%s\n", message.To, message.Subject, message.Body)
+	return nil
+}
@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends mail through an SMTP server. STARTTLS is used when the
+// server offers it, and the credentials are only sent when Username is set,
+// so a local SMTP stand-in without TLS and auth works as well.
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func NewSMTPMailer(addr string, from string, username string, password string) *SMTPMailer {
+	return &SMTPMailer{
+		Addr:     addr,
+		From:     from,
+		Username: username,
+		Password: password,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message Message) error {
+	data, err := format(m.From, message)
+	if err != nil {
+		return fmt.Errorf(ErrSendingMail+": %w", err)
+	}
+
+	if err = m.send(ctx, message.To, data); err != nil {
+		return fmt.Errorf(ErrSendingMail+": %w", err)
+	}
+
+	return nil
+}
+
+func (m *SMTPMailer) send(ctx context.Context, to string, data []byte) error {
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if m.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(m.From); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
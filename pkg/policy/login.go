@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LoginPolicy describes the logins users may register. A login may only
+// consist of letters, digits and AllowedSymbols, and all its letters have to
+// be of one script, so that look-alike logins such as "admin" with a Cyrillic
+// "а" can't be registered. Lengths are counted in characters.
+type LoginPolicy struct {
+	MinLength      int
+	MaxLength      int
+	AllowedSymbols string
+}
+
+// NormalizeLogin returns the NFKC form of the login, so that logins that look
+// the same, e.g. written with fullwidth and ordinary letters, are the same
+// login. Logins are normalized before they're checked, stored or looked up.
+func NormalizeLogin(login string) string {
+	return norm.NFKC.String(login)
+}
+
+// Check returns the rules the normalized login breaks.
+func (p LoginPolicy) Check(login string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(login)
+	if length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Param: strconv.Itoa(p.MinLength)})
+	}
+	if length > p.MaxLength {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Param: strconv.Itoa(p.MaxLength)})
+	}
+
+	for _, r := range login {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(p.AllowedSymbols, r) {
+			violations = append(violations, Violation{Rule: RuleCharset, Param: p.AllowedSymbols})
+			break
+		}
+	}
+
+	if hasMixedScripts(login) {
+		violations = append(violations, Violation{Rule: RuleMixedScripts})
+	}
+
+	return violations
+}
+
+// hasMixedScripts reports whether the letters of s belong to more than one
+// script.
+func hasMixedScripts(s string) bool {
+	var first *unicode.RangeTable
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		if first == nil {
+			first = letterScript(r)
+			continue
+		}
+		if !unicode.Is(first, r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func letterScript(r rune) *unicode.RangeTable {
+	for _, script := range unicode.Scripts {
+		if unicode.Is(script, r) {
+			return script
+		}
+	}
+
+	// Every letter has a script, this is only a fallback that makes the
+	// letter a script of its own.
+	return &unicode.RangeTable{R32: []unicode.Range32{{Lo: uint32(r), Hi: uint32(r), Stride: 1}}}
+}
@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLogin(t *testing.T) {
+	tests := []struct {
+		name  string
+		login string
+		want  string
+	}{
+		{name: "ascii", login: "alice", want: "alice"},
+		{name: "fullwidth letters", login: "ａｌｉｃｅ", want: "alice"},
+		{name: "fullwidth digits", login: "bob１２", want: "bob12"},
+		{name: "ligature", login: "ﬁona", want: "fiona"},
+		{name: "kelvin sign", login: "Kate", want: "Kate"},
+		{name: "combining accent", login: "josé", want: "josé"},
+		{name: "precomposed accent", login: "josé", want: "josé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeLogin(tt.login); got != tt.want {
+				t.Errorf("NormalizeLogin(%q) = %q, want %q", tt.login, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeLoginCollisions checks that logins that only differ in width,
+// compatibility characters or case are the same login once normalized and
+// lowercased, as logins are unique ignoring case.
+func TestNormalizeLoginCollisions(t *testing.T) {
+	tests := []struct {
+		a string
+		b string
+	}{
+		{a: "Admin", b: "admin"},
+		{a: "ＡＤＭＩＮ", b: "admin"},
+		{a: "Ａｄｍｉｎ", b: "ADMIN"},
+		{a: "Kate", b: "kate"},
+		{a: "ﬁona", b: "FIONA"},
+		{a: "José", b: "josé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a, func(t *testing.T) {
+			a := strings.ToLower(NormalizeLogin(tt.a))
+			b := strings.ToLower(NormalizeLogin(tt.b))
+			if a != b {
+				t.Errorf("%q and %q are normalized to %q and %q", tt.a, tt.b, a, b)
+			}
+		})
+	}
+}
+
+func TestLoginPolicyCheck(t *testing.T) {
+	policy := LoginPolicy{
+		MinLength:      3,
+		MaxLength:      8,
+		AllowedSymbols: "._-",
+	}
+
+	tests := []struct {
+		name  string
+		login string
+		want  []Violation
+	}{
+		{name: "valid", login: "alice", want: nil},
+		{name: "symbols and digits", login: "a.b_c-1", want: nil},
+		{name: "cyrillic", login: "иван", want: nil},
+		{name: "greek", login: "αλφα", want: nil},
+		{name: "accented latin", login: "josé", want: nil},
+		{name: "too short", login: "al", want: []Violation{{Rule: RuleMinLength, Param: "3"}}},
+		{name: "too long", login: "alice.smith", want: []Violation{{Rule: RuleMaxLength, Param: "8"}}},
+		{name: "length in characters", login: "иванович", want: nil},
+		{name: "space", login: "al ice", want: []Violation{{Rule: RuleCharset, Param: "._-"}}},
+		{name: "other symbol", login: "al@ice", want: []Violation{{Rule: RuleCharset, Param: "._-"}}},
+		{name: "cyrillic a in latin", login: "аdmin", want: []Violation{{Rule: RuleMixedScripts}}},
+		{name: "greek o in latin", login: "bοb", want: []Violation{{Rule: RuleMixedScripts}}},
+		{name: "digits don't mix scripts", login: "иван42", want: nil},
+		{
+			name:  "several rules",
+			login: "а ",
+			want: []Violation{
+				{Rule: RuleMinLength, Param: "3"},
+				{Rule: RuleCharset, Param: "._-"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Check(tt.login); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.login, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginPolicyCheckNoSymbols(t *testing.T) {
+	policy := LoginPolicy{MinLength: 1, MaxLength: 32}
+
+	if got := policy.Check("alice_1"); !reflect.DeepEqual(got, []Violation{{Rule: RuleCharset}}) {
+		t.Errorf("Check() = %v, want a charset violation", got)
+	}
+	if got := policy.Check("alice1"); got != nil {
+		t.Errorf("Check() = %v, want no violations", got)
+	}
+}
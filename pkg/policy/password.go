@@ -0,0 +1,93 @@
+package policy
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// commonPasswords are the lowercased passwords of common_passwords.txt.
+var commonPasswords = parseCommonPasswords(commonPasswordsFile)
+
+// PasswordPolicy describes the passwords users may set. Lengths are counted in
+// characters. A password has to contain characters of at least MinClasses of
+// the classes: lowercase letters, uppercase letters, digits and other
+// characters. RejectLogin rejects passwords that contain the login,
+// RejectCommon rejects the passwords of the bundled list of common passwords,
+// both ignoring case.
+type PasswordPolicy struct {
+	MinLength    int
+	MaxLength    int
+	MinClasses   int
+	RejectLogin  bool
+	RejectCommon bool
+}
+
+// Check returns the rules the password of the user with the normalized login
+// breaks.
+func (p PasswordPolicy) Check(password string, login string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Param: strconv.Itoa(p.MinLength)})
+	}
+	if length > p.MaxLength {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Param: strconv.Itoa(p.MaxLength)})
+	}
+
+	if characterClasses(password) < p.MinClasses {
+		violations = append(violations, Violation{Rule: RuleCharacterClasses, Param: strconv.Itoa(p.MinClasses)})
+	}
+
+	lowered := strings.ToLower(password)
+	if p.RejectLogin && login != "" && strings.Contains(lowered, strings.ToLower(login)) {
+		violations = append(violations, Violation{Rule: RuleContainsLogin})
+	}
+	if _, ok := commonPasswords[lowered]; p.RejectCommon && ok {
+		violations = append(violations, Violation{Rule: RuleCommonPassword})
+	}
+
+	return violations
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+
+	return classes
+}
+
+func parseCommonPasswords(file string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(file, "\n") {
+		if password := strings.TrimSpace(line); password != "" {
+			passwords[strings.ToLower(password)] = struct{}{}
+		}
+	}
+
+	return passwords
+}
@@ -0,0 +1,114 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:    10,
+		MaxLength:    20,
+		MinClasses:   3,
+		RejectLogin:  true,
+		RejectCommon: true,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		login    string
+		want     []Violation
+	}{
+		{name: "valid", password: "Tr0ub4dor&3", login: "alice", want: nil},
+		{name: "three classes", password: "correcthorse42!", login: "alice", want: nil},
+		{name: "too short", password: "Ab1!", login: "alice", want: []Violation{{Rule: RuleMinLength, Param: "10"}}},
+		{
+			name:     "too long",
+			password: "Correct-Horse-Battery-Staple-1",
+			login:    "alice",
+			want:     []Violation{{Rule: RuleMaxLength, Param: "20"}},
+		},
+		{name: "length in characters", password: "пароль-Пароль1", login: "alice", want: nil},
+		{
+			name:     "two classes",
+			password: "correcthorse",
+			login:    "alice",
+			want:     []Violation{{Rule: RuleCharacterClasses, Param: "3"}},
+		},
+		{
+			name:     "non-ascii classes",
+			password: "пароль-ПАРОЛЬ",
+			login:    "alice",
+			want:     nil,
+		},
+		{
+			name:     "contains login",
+			password: "alice-Rules-42",
+			login:    "alice",
+			want:     []Violation{{Rule: RuleContainsLogin}},
+		},
+		{
+			name:     "contains login in another case",
+			password: "ALICE-rules-42",
+			login:    "Alice",
+			want:     []Violation{{Rule: RuleContainsLogin}},
+		},
+		{
+			name:     "no login",
+			password: "alice-Rules-42",
+			login:    "",
+			want:     nil,
+		},
+		{
+			name:     "common password",
+			password: "password",
+			login:    "alice",
+			want: []Violation{
+				{Rule: RuleMinLength, Param: "10"},
+				{Rule: RuleCharacterClasses, Param: "3"},
+				{Rule: RuleCommonPassword},
+			},
+		},
+		{
+			name:     "common password in another case",
+			password: "QWERTY",
+			login:    "alice",
+			want: []Violation{
+				{Rule: RuleMinLength, Param: "10"},
+				{Rule: RuleCharacterClasses, Param: "3"},
+				{Rule: RuleCommonPassword},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Check(tt.password, tt.login); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q, %q) = %v, want %v", tt.password, tt.login, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyCheckDisabledRules(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 1, MaxLength: 128}
+
+	for _, password := range []string{"password", "alice123"} {
+		if got := policy.Check(password, "alice"); got != nil {
+			t.Errorf("Check(%q) = %v, want no violations", password, got)
+		}
+	}
+}
+
+func TestCommonPasswords(t *testing.T) {
+	if len(commonPasswords) == 0 {
+		t.Fatal("no common passwords are loaded")
+	}
+
+	for password := range commonPasswords {
+		if password == "" {
+			t.Error("an empty common password is loaded")
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package policy
+
+// Rules of the policies, a Violation names the rule it breaks.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleCharacterClasses = "character_classes"
+	RuleContainsLogin    = "contains_login"
+	RuleCommonPassword   = "common_password"
+	RuleCharset          = "charset"
+	RuleMixedScripts     = "mixed_scripts"
+)
+
+// Violation is a rule that a login or a password breaks. Param is the
+// parameter of the rule, e.g. the minimum length, if it has one.
+type Violation struct {
+	Rule  string
+	Param string
+}
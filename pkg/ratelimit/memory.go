@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore keeps token buckets in memory. It's only suitable for a single
+// instance of the service, every instance would have its own buckets.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]bucket),
+	}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = bucket{tokens: float64(limit.Requests), updatedAt: now}
+	}
+
+	tokens, allowed := limit.Take(b.tokens, now.Sub(b.updatedAt))
+	s.buckets[key] = bucket{
+		tokens:    tokens,
+		updatedAt: now,
+		expiresAt: now.Add(limit.Period),
+	}
+
+	return tokens, allowed, nil
+}
+
+func (s *MemoryStore) DeleteExpired(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, b := range s.buckets {
+		if !now.Before(b.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
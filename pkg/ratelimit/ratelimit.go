@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	errParsingLimit         = "limit must look like 60/1m"
+	errDeletingExpiredLimit = "error deleting expired rate limits"
+
+	cleanupInterval = 10 * time.Minute
+)
+
+// Limit is a token bucket that holds up to Requests tokens and refills them
+// all over Period. Every request takes a token, so bursts of up to Requests
+// requests are allowed, but no more than Requests requests per Period in the
+// long run.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses a limit in the requests/period form, e.g. 60/1m.
+func ParseLimit(s string) (Limit, error) {
+	requestsStr, periodStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, errors.New(errParsingLimit)
+	}
+
+	requests, err := strconv.Atoi(requestsStr)
+	if err != nil || requests <= 0 {
+		return Limit{}, errors.New(errParsingLimit)
+	}
+
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Limit{}, errors.New(errParsingLimit)
+	}
+
+	return Limit{Requests: requests, Period: period}, nil
+}
+
+// Rate returns how many tokens are refilled per second.
+func (l Limit) Rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Take refills the bucket that held tokens elapsed ago and takes a token from
+// it if there's one. It returns the tokens left and whether a token was
+// taken.
+func (l Limit) Take(tokens float64, elapsed time.Duration) (float64, bool) {
+	tokens = min(tokens+elapsed.Seconds()*l.Rate(), float64(l.Requests))
+	if tokens < 1 {
+		return tokens, false
+	}
+
+	return tokens - 1, true
+}
+
+// Store keeps the token buckets. A bucket only has to be kept until it's
+// full again, a missing bucket is the same as a full one.
+type Store interface {
+	// Take takes a token from the bucket of the key, see Limit.Take.
+	Take(ctx context.Context, key string, limit Limit) (float64, bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// Result is the outcome of a request. Reset is when the bucket is full again,
+// RetryAfter is when the next token is available if the request wasn't
+// allowed.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Limiter limits requests with token buckets kept in its store.
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+	}
+}
+
+// Allow takes a token from the bucket of the key.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	tokens, allowed, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(limit.Requests) - tokens) / limit.Rate()),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate())
+	}
+
+	return result, nil
+}
+
+// Cleanup deletes full buckets from store every cleanupInterval until ctx is
+// done.
+func Cleanup(ctx context.Context, store Store) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := store.DeleteExpired(ctx); err != nil {
+			log.Printf(errDeletingExpiredLimit+": %s\n", err)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
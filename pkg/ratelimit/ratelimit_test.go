@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Limit
+		wantErr bool
+	}{
+		{s: "60/1m", want: Limit{Requests: 60, Period: time.Minute}},
+		{s: "5/30s", want: Limit{Requests: 5, Period: 30 * time.Second}},
+		{s: "1000/1h", want: Limit{Requests: 1000, Period: time.Hour}},
+		{s: "60", wantErr: true},
+		{s: "60/", wantErr: true},
+		{s: "/1m", wantErr: true},
+		{s: "0/1m", wantErr: true},
+		{s: "-1/1m", wantErr: true},
+		{s: "60/0s", wantErr: true},
+		{s: "60/-1m", wantErr: true},
+		{s: "60/minute", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseLimit(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitTake(t *testing.T) {
+	// A token every 6 seconds.
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	tests := []struct {
+		name        string
+		tokens      float64
+		elapsed     time.Duration
+		wantTokens  float64
+		wantAllowed bool
+	}{
+		{name: "full bucket", tokens: 10, elapsed: 0, wantTokens: 9, wantAllowed: true},
+		{name: "refill is capped", tokens: 10, elapsed: time.Hour, wantTokens: 9, wantAllowed: true},
+		{name: "last token", tokens: 1, elapsed: 0, wantTokens: 0, wantAllowed: true},
+		{name: "empty bucket", tokens: 0, elapsed: 0, wantTokens: 0, wantAllowed: false},
+		{name: "partly refilled", tokens: 0, elapsed: 3 * time.Second, wantTokens: 0.5, wantAllowed: false},
+		{name: "refilled a token", tokens: 0, elapsed: 6 * time.Second, wantTokens: 0, wantAllowed: true},
+		{name: "refilled some tokens", tokens: 2, elapsed: 30 * time.Second, wantTokens: 6, wantAllowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, allowed := limit.Take(tt.tokens, tt.elapsed)
+			if tokens != tt.wantTokens || allowed != tt.wantAllowed {
+				t.Errorf("Take() = %v, %v, want %v, %v", tokens, allowed, tt.wantTokens, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(NewMemoryStore())
+	limit := Limit{Requests: 3, Period: time.Hour}
+
+	for i := 0; i < limit.Requests; i++ {
+		result, err := limiter.Allow(ctx, "alice", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Fatalf("request %d isn't allowed", i+1)
+		}
+		if result.Limit != limit.Requests || result.Remaining != limit.Requests-1-i {
+			t.Errorf("request %d: Limit = %d, Remaining = %d", i+1, result.Limit, result.Remaining)
+		}
+		if result.RetryAfter != 0 {
+			t.Errorf("request %d: RetryAfter = %s, want 0", i+1, result.RetryAfter)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, "alice", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Fatal("request over the limit is allowed")
+	}
+	if result.Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", result.Remaining)
+	}
+	// A token is refilled every 20 minutes and the bucket is full again
+	// after an hour.
+	if result.RetryAfter <= 19*time.Minute || result.RetryAfter > 20*time.Minute {
+		t.Errorf("RetryAfter = %s, want about 20m", result.RetryAfter)
+	}
+	if result.Reset <= 59*time.Minute || result.Reset > time.Hour {
+		t.Errorf("Reset = %s, want about 1h", result.Reset)
+	}
+
+	result, err = limiter.Allow(ctx, "bob", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed {
+		t.Error("request of another key isn't allowed")
+	}
+}
@@ -1,6 +1,7 @@
 package spell
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -14,7 +15,7 @@ const (
 )
 
 type Speller interface {
-	CheckText(text string) ([]SpellingError, error)
+	CheckText(ctx context.Context, text string) ([]SpellingError, error)
 	FormatErrors(errors []SpellingError) string
 }
 
@@ -38,16 +39,22 @@ type SpellingError struct {
 	S    []string `json:"s"`
 }
 
-func (s *YandexSpeller) CheckText(text string) ([]SpellingError, error) {
-	resp, err := http.PostForm(s.SpellerURL, url.Values{"text": {text}})
+func (s *YandexSpeller) CheckText(ctx context.Context, text string) ([]SpellingError, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.SpellerURL, strings.NewReader(url.Values{"text": {text}}.Encode()))
 	if err != nil {
-		return nil, fmt.Errorf(ErrCheckingText+": %v", err)
+		return nil, fmt.Errorf(ErrCheckingText+": %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf(ErrCheckingText+": %w", err)
 	}
 	defer resp.Body.Close()
 
 	var spellingErrors []SpellingError
 	if err = json.NewDecoder(resp.Body).Decode(&spellingErrors); err != nil {
-		return nil, fmt.Errorf(ErrDecodingResponse+": %v", err)
+		return nil, fmt.Errorf(ErrDecodingResponse+": %w", err)
 	}
 
 	return spellingErrors, nil